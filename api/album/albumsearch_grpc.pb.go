@@ -23,6 +23,7 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type AlbumClient interface {
 	Find(ctx context.Context, in *FindAlbumRequest, opts ...grpc.CallOption) (*FindAlbumResponse, error)
+	FindStream(ctx context.Context, in *FindAlbumRequest, opts ...grpc.CallOption) (Album_FindStreamClient, error)
 	ServiceStatus(ctx context.Context, in *AlbumServiceStatusRequest, opts ...grpc.CallOption) (*AlbumServiceStatusResponse, error)
 }
 
@@ -43,6 +44,38 @@ func (c *albumClient) Find(ctx context.Context, in *FindAlbumRequest, opts ...gr
 	return out, nil
 }
 
+func (c *albumClient) FindStream(ctx context.Context, in *FindAlbumRequest, opts ...grpc.CallOption) (Album_FindStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Album_ServiceDesc.Streams[0], "/album/FindStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &albumFindStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Album_FindStreamClient interface {
+	Recv() (*FindAlbumResponse, error)
+	grpc.ClientStream
+}
+
+type albumFindStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *albumFindStreamClient) Recv() (*FindAlbumResponse, error) {
+	m := new(FindAlbumResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *albumClient) ServiceStatus(ctx context.Context, in *AlbumServiceStatusRequest, opts ...grpc.CallOption) (*AlbumServiceStatusResponse, error) {
 	out := new(AlbumServiceStatusResponse)
 	err := c.cc.Invoke(ctx, "/album/ServiceStatus", in, out, opts...)
@@ -57,6 +90,7 @@ func (c *albumClient) ServiceStatus(ctx context.Context, in *AlbumServiceStatusR
 // for forward compatibility
 type AlbumServer interface {
 	Find(context.Context, *FindAlbumRequest) (*FindAlbumResponse, error)
+	FindStream(*FindAlbumRequest, Album_FindStreamServer) error
 	ServiceStatus(context.Context, *AlbumServiceStatusRequest) (*AlbumServiceStatusResponse, error)
 	mustEmbedUnimplementedAlbumServer()
 }
@@ -68,6 +102,9 @@ type UnimplementedAlbumServer struct {
 func (UnimplementedAlbumServer) Find(context.Context, *FindAlbumRequest) (*FindAlbumResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Find not implemented")
 }
+func (UnimplementedAlbumServer) FindStream(*FindAlbumRequest, Album_FindStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method FindStream not implemented")
+}
 func (UnimplementedAlbumServer) ServiceStatus(context.Context, *AlbumServiceStatusRequest) (*AlbumServiceStatusResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ServiceStatus not implemented")
 }
@@ -102,6 +139,27 @@ func _Album_Find_Handler(srv interface{}, ctx context.Context, dec func(interfac
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Album_FindStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(FindAlbumRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AlbumServer).FindStream(m, &albumFindStreamServer{stream})
+}
+
+type Album_FindStreamServer interface {
+	Send(*FindAlbumResponse) error
+	grpc.ServerStream
+}
+
+type albumFindStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *albumFindStreamServer) Send(m *FindAlbumResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _Album_ServiceStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(AlbumServiceStatusRequest)
 	if err := dec(in); err != nil {
@@ -136,6 +194,12 @@ var Album_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _Album_ServiceStatus_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "FindStream",
+			Handler:       _Album_FindStream_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "api/album/albumsearch.proto",
 }
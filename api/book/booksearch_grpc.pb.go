@@ -23,6 +23,7 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type BookClient interface {
 	Find(ctx context.Context, in *FindBookRequest, opts ...grpc.CallOption) (*FindBookResponse, error)
+	FindStream(ctx context.Context, in *FindBookRequest, opts ...grpc.CallOption) (Book_FindStreamClient, error)
 	ServiceStatus(ctx context.Context, in *BookServiceStatusRequest, opts ...grpc.CallOption) (*BookServiceStatusResponse, error)
 }
 
@@ -43,6 +44,38 @@ func (c *bookClient) Find(ctx context.Context, in *FindBookRequest, opts ...grpc
 	return out, nil
 }
 
+func (c *bookClient) FindStream(ctx context.Context, in *FindBookRequest, opts ...grpc.CallOption) (Book_FindStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Book_ServiceDesc.Streams[0], "/book/FindStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &bookFindStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Book_FindStreamClient interface {
+	Recv() (*FindBookResponse, error)
+	grpc.ClientStream
+}
+
+type bookFindStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *bookFindStreamClient) Recv() (*FindBookResponse, error) {
+	m := new(FindBookResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *bookClient) ServiceStatus(ctx context.Context, in *BookServiceStatusRequest, opts ...grpc.CallOption) (*BookServiceStatusResponse, error) {
 	out := new(BookServiceStatusResponse)
 	err := c.cc.Invoke(ctx, "/book/ServiceStatus", in, out, opts...)
@@ -57,6 +90,7 @@ func (c *bookClient) ServiceStatus(ctx context.Context, in *BookServiceStatusReq
 // for forward compatibility
 type BookServer interface {
 	Find(context.Context, *FindBookRequest) (*FindBookResponse, error)
+	FindStream(*FindBookRequest, Book_FindStreamServer) error
 	ServiceStatus(context.Context, *BookServiceStatusRequest) (*BookServiceStatusResponse, error)
 	mustEmbedUnimplementedBookServer()
 }
@@ -68,6 +102,9 @@ type UnimplementedBookServer struct {
 func (UnimplementedBookServer) Find(context.Context, *FindBookRequest) (*FindBookResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Find not implemented")
 }
+func (UnimplementedBookServer) FindStream(*FindBookRequest, Book_FindStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method FindStream not implemented")
+}
 func (UnimplementedBookServer) ServiceStatus(context.Context, *BookServiceStatusRequest) (*BookServiceStatusResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ServiceStatus not implemented")
 }
@@ -102,6 +139,27 @@ func _Book_Find_Handler(srv interface{}, ctx context.Context, dec func(interface
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Book_FindStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(FindBookRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BookServer).FindStream(m, &bookFindStreamServer{stream})
+}
+
+type Book_FindStreamServer interface {
+	Send(*FindBookResponse) error
+	grpc.ServerStream
+}
+
+type bookFindStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *bookFindStreamServer) Send(m *FindBookResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _Book_ServiceStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(BookServiceStatusRequest)
 	if err := dec(in); err != nil {
@@ -136,6 +194,12 @@ var Book_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _Book_ServiceStatus_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "FindStream",
+			Handler:       _Book_FindStream_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "api/book/booksearch.proto",
 }
@@ -11,6 +11,7 @@ import (
 
 	albumpb "microservices-with-go/api/album"
 	album "microservices-with-go/pkg/albumsearch"
+	"microservices-with-go/pkg/registry"
 
 	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
 	kitgrpc "github.com/go-kit/kit/transport/grpc"
@@ -18,6 +19,7 @@ import (
 	stdprometheus "github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/viper"
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 func main() {
@@ -25,6 +27,9 @@ func main() {
 	viper.SetConfigType("yaml")
 	viper.AddConfigPath("../../configs/")
 	viper.SetDefault("maxNumberResponse", 5)
+	viper.SetDefault("registry.heartbeat", "10s")
+	viper.SetDefault("health.interval", "10s")
+	viper.SetDefault("health.timeout", "2s")
 	err := viper.ReadInConfig()
 	if err != nil {
 		panic(fmt.Errorf("fatal error config file: %w", err))
@@ -47,6 +52,8 @@ func main() {
 
 	var service album.AlbumService
 	service = album.NewService()
+	healthChecker := album.NewHealthChecker(viper.GetDuration("health.timeout"), service)
+	service = healthChecker
 	service = album.LoggingMiddleware{Logger: logger, Next: service}
 	service = album.InstrumentingMiddleware{RequestCount: requestCount, RequestLatency: requestLatency, Next: service}
 
@@ -61,13 +68,53 @@ func main() {
 			logger.Log("transport", "gRPC", "during", "Listen", "err", err)
 			os.Exit(1)
 		}
+		baseServer := grpc.NewServer(grpc.UnaryInterceptor(kitgrpc.Interceptor))
+		albumpb.RegisterAlbumServer(baseServer, grpcServer)
+		healthpb.RegisterHealthServer(baseServer, healthChecker.Server())
 		g.Add(func() error {
 			logger.Log("transport", "gRPC", "addr", grpcAddress)
-			baseServer := grpc.NewServer(grpc.UnaryInterceptor(kitgrpc.Interceptor))
-			albumpb.RegisterAlbumServer(baseServer, grpcServer)
 			return baseServer.Serve(grpcListener)
 		}, func(error) {
-			grpcListener.Close()
+			healthChecker.Shutdown()
+			baseServer.GracefulStop()
+		})
+	}
+	if registryAddress := viper.GetString("registry.address"); registryAddress != "" {
+		registryConnection, err := grpc.Dial(registryAddress, grpc.WithInsecure())
+		if err != nil {
+			logger.Log("transport", "gRPC", "during", "Dial", "err", err)
+			os.Exit(1)
+		}
+		advertiseAddress := viper.GetString("registry.advertiseAddress")
+		if advertiseAddress == "" {
+			advertiseAddress = grpcAddress
+		}
+		registrar := registry.NewRegistrar(registry.NewGRPCClient(registryConnection), "album", advertiseAddress, viper.GetDuration("registry.heartbeat"), logger)
+		quit := make(chan struct{})
+		g.Add(func() error {
+			defer registryConnection.Close()
+			// Register once the listener is up and the first health check
+			// has passed, so that the core never routes to an instance that
+			// cannot answer yet.
+			select {
+			case <-healthChecker.Serving():
+			case <-quit:
+				return nil
+			}
+			registrar.Register()
+			<-quit
+			registrar.Deregister()
+			return nil
+		}, func(error) {
+			close(quit)
+		})
+	}
+	{
+		stop := make(chan struct{})
+		g.Add(func() error {
+			return healthChecker.Run(viper.GetDuration("health.interval"), stop)
+		}, func(error) {
+			close(stop)
 		})
 	}
 	{
@@ -77,6 +124,7 @@ func main() {
 			signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
 			select {
 			case sig := <-c:
+				healthChecker.Shutdown()
 				return fmt.Errorf("received signal %s", sig)
 			case <-cancelInterrupt:
 				return nil
@@ -1,43 +1,534 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/go-kit/log"
+	"github.com/spf13/viper"
 
+	corepb "microservices-with-go/api/core"
+	registrypb "microservices-with-go/api/registry"
 	"microservices-with-go/pkg/core"
+	"microservices-with-go/pkg/registry"
 
+	"github.com/go-kit/kit/endpoint"
 	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	kitgrpc "github.com/go-kit/kit/transport/grpc"
+	"github.com/oklog/oklog/pkg/group"
 	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 func main() {
+	viper.SetConfigName("core")
+	viper.SetConfigType("yaml")
+	viper.AddConfigPath("../../configs/")
+	viper.SetEnvPrefix("core")
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.AutomaticEnv()
+	viper.SetDefault("http.address", "localhost:8080")
+	viper.SetDefault("grpc.address", "localhost:8084")
+	viper.SetDefault("metrics.namespace", "assessment_application")
+	viper.SetDefault("metrics.subsystem", "user_search_query_propagator")
+	viper.SetDefault("http.readTimeout", "5s")
+	viper.SetDefault("http.writeTimeout", "30s")
+	viper.SetDefault("http.idleTimeout", "2m")
+	viper.SetDefault("shutdown.delay", "0s")
+	viper.SetDefault("shutdown.drainTimeout", "15s")
+	viper.SetDefault("registry.ttl", "30s")
+	viper.SetDefault("ranking", "score")
+	viper.SetDefault("limit.default", 5)
+	viper.SetDefault("limit.max", 50)
+	viper.SetDefault("cache.size", 1000)
+	viper.SetDefault("cache.ttl", "1m")
+	viper.SetDefault("rateLimit.perSecond", 10)
+	viper.SetDefault("rateLimit.burst", 20)
+	viper.SetDefault("rateLimit.address.perSecond", 50)
+	viper.SetDefault("rateLimit.address.burst", 100)
+	viper.SetDefault("concurrency.maxInFlight", 100)
+	viper.SetDefault("status.timeout", "1s")
+	viper.SetDefault("startup.timeout", "10s")
+	err := viper.ReadInConfig()
+	if err != nil {
+		panic(fmt.Errorf("fatal error config file: %w", err))
+	}
+	if err := validateConfig(); err != nil {
+		panic(fmt.Errorf("fatal error config: %w", err))
+	}
 	logger := log.NewLogfmtLogger(os.Stderr)
 
+	var instances *registry.Registry
+	registryAddress := viper.GetString("registry.address")
+	if registryAddress != "" {
+		instances = registry.NewRegistry(viper.GetDuration("registry.ttl"), core.ValidateSourceType)
+		defer instances.Stop()
+	}
+
+	var sourceConfigs []core.SourceConfig
+	if err := viper.UnmarshalKey("sources", &sourceConfigs); err != nil {
+		panic(fmt.Errorf("fatal error reading sources: %w", err))
+	}
+	if err := overrideSources(sourceConfigs); err != nil {
+		panic(fmt.Errorf("fatal error config: %w", err))
+	}
+	sources, err := core.NewSourceRegistry(sourceConfigs, instances)
+	if err != nil {
+		panic(fmt.Errorf("fatal error creating sources: %w", err))
+	}
+	defer func() {
+		if err := sources.Close(); err != nil {
+			logger.Log("during", "Close", "err", err)
+		}
+	}()
+
 	fieldKeys := []string{"method", "error"}
 	requestCount := kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
-		Namespace: "assessment_application",
-		Subsystem: "user_search_query_propagator",
+		Namespace: viper.GetString("metrics.namespace"),
+		Subsystem: viper.GetString("metrics.subsystem"),
 		Name:      "request_count",
 		Help:      "Number of requests received.",
 	}, fieldKeys)
 	requestLatency := kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
-		Namespace: "assessment_application",
-		Subsystem: "user_search_query_propagator",
+		Namespace: viper.GetString("metrics.namespace"),
+		Subsystem: viper.GetString("metrics.subsystem"),
 		Name:      "request_latency_microseconds",
 		Help:      "Total duration of requests in microseconds.",
 	}, fieldKeys)
 
+	cacheHits := kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: viper.GetString("metrics.namespace"),
+		Subsystem: viper.GetString("metrics.subsystem"),
+		Name:      "cache_hits",
+		Help:      "Number of searches answered from the cache.",
+	}, []string{})
+	cacheMisses := kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: viper.GetString("metrics.namespace"),
+		Subsystem: viper.GetString("metrics.subsystem"),
+		Name:      "cache_misses",
+		Help:      "Number of searches not found in the cache.",
+	}, []string{})
+	cacheEvictions := kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: viper.GetString("metrics.namespace"),
+		Subsystem: viper.GetString("metrics.subsystem"),
+		Name:      "cache_evictions",
+		Help:      "Number of entries dropped from the cache.",
+	}, []string{"reason"})
+
 	var service core.QueryService
-	service = core.NewService()
+	serviceConfig := core.ServiceConfig{
+		Ranking:       viper.GetString("ranking"),
+		DefaultLimit:  viper.GetInt("limit.default"),
+		MaxLimit:      viper.GetInt("limit.max"),
+		StatusTimeout: viper.GetDuration("status.timeout"),
+	}
+	service, err = core.NewService(sources, serviceConfig)
+	if err != nil {
+		panic(fmt.Errorf("fatal error creating service: %w", err))
+	}
+	var cache *core.CachingMiddleware
+	if cacheSize := viper.GetInt("cache.size"); cacheSize > 0 {
+		cache = core.NewCachingMiddleware(cacheSize, viper.GetDuration("cache.ttl"), serviceConfig, cacheHits, cacheMisses, cacheEvictions, service)
+		service = cache
+	}
 	service = core.LoggingMiddleware{Logger: logger, Next: service}
 	service = core.InstrumentingMiddleware{RequestCount: requestCount, RequestLatency: requestLatency, Next: service}
 
+	readiness := core.NewReadiness()
 	endpoints := core.NewEndpointSet(service)
-	searchQueryHandler := core.NewHTTPHandler(endpoints)
+	var authenticate endpoint.Middleware
+	if keyFile := viper.GetString("auth.keyFile"); keyFile != "" {
+		keys := viper.New()
+		keys.SetConfigFile(keyFile)
+		if err := keys.ReadInConfig(); err != nil {
+			panic(fmt.Errorf("fatal error reading key file: %w", err))
+		}
+		var apiKeys []core.APIKey
+		if err := keys.UnmarshalKey("keys", &apiKeys); err != nil {
+			panic(fmt.Errorf("fatal error reading keys: %w", err))
+		}
+		apiKeyRequests := kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: viper.GetString("metrics.namespace"),
+			Subsystem: viper.GetString("metrics.subsystem"),
+			Name:      "api_key_requests",
+			Help:      "Number of searches per API key and whether they were let through.",
+		}, []string{"key", "result"})
+		if authenticate, err = core.NewAPIKeyMiddleware(apiKeys, apiKeyRequests); err != nil {
+			panic(fmt.Errorf("fatal error loading keys: %w", err))
+		}
+	}
+	// The settings are read one by one so that environment variables
+	// override them, which UnmarshalKey would miss. The keys list can only
+	// be set in the file.
+	jwtConfig := core.JWTConfig{
+		Issuer:   viper.GetString("auth.jwt.issuer"),
+		Audience: viper.GetString("auth.jwt.audience"),
+		JWKSFile: viper.GetString("auth.jwt.jwksFile"),
+	}
+	if err := viper.UnmarshalKey("auth.jwt.keys", &jwtConfig.Keys); err != nil {
+		panic(fmt.Errorf("fatal error reading auth.jwt.keys: %w", err))
+	}
+	if len(jwtConfig.Keys) > 0 || jwtConfig.JWKSFile != "" {
+		// Requests without a bearer token fall back to API keys.
+		if authenticate, err = core.NewJWTMiddleware(jwtConfig, authenticate); err != nil {
+			panic(fmt.Errorf("fatal error loading JWT keys: %w", err))
+		}
+		if viper.GetBool("auth.jwt.sourceScopes") {
+			scopes := core.NewSourceScopeMiddleware(sources)
+			endpoints.SearchEndpoint = scopes(endpoints.SearchEndpoint)
+			endpoints.SearchStreamEndpoint = scopes(endpoints.SearchStreamEndpoint)
+		}
+	}
+	rateLimited := kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: viper.GetString("metrics.namespace"),
+		Subsystem: viper.GetString("metrics.subsystem"),
+		Name:      "rate_limited_requests",
+		Help:      "Number of searches refused for going over a client's rate limit.",
+	}, []string{"client"})
+	if viper.GetFloat64("rateLimit.perSecond") > 0 {
+		limit, err := core.NewClientRateLimitMiddleware(core.RateLimitConfig{
+			PerSecond:         viper.GetFloat64("rateLimit.perSecond"),
+			Burst:             viper.GetInt("rateLimit.burst"),
+			TrustForwardedFor: viper.GetBool("rateLimit.trustForwardedFor"),
+		}, rateLimited)
+		if err != nil {
+			panic(fmt.Errorf("fatal error config rateLimit: %w", err))
+		}
+		endpoints.SearchEndpoint = limit(endpoints.SearchEndpoint)
+		endpoints.SearchStreamEndpoint = limit(endpoints.SearchStreamEndpoint)
+	}
+	if authenticate != nil {
+		endpoints.SearchEndpoint = authenticate(endpoints.SearchEndpoint)
+		endpoints.SearchStreamEndpoint = authenticate(endpoints.SearchStreamEndpoint)
+	}
+	if viper.GetFloat64("rateLimit.address.perSecond") > 0 {
+		limit, err := core.NewAddressRateLimitMiddleware(core.RateLimitConfig{
+			PerSecond:         viper.GetFloat64("rateLimit.address.perSecond"),
+			Burst:             viper.GetInt("rateLimit.address.burst"),
+			TrustForwardedFor: viper.GetBool("rateLimit.trustForwardedFor"),
+		}, rateLimited)
+		if err != nil {
+			panic(fmt.Errorf("fatal error config rateLimit.address: %w", err))
+		}
+		endpoints.SearchEndpoint = limit(endpoints.SearchEndpoint)
+		endpoints.SearchStreamEndpoint = limit(endpoints.SearchStreamEndpoint)
+	}
+	if maxInFlight := viper.GetInt("concurrency.maxInFlight"); maxInFlight > 0 {
+		inflight := kitprometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: viper.GetString("metrics.namespace"),
+			Subsystem: viper.GetString("metrics.subsystem"),
+			Name:      "inflight_searches",
+			Help:      "Number of searches being served.",
+		}, []string{})
+		shed := kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: viper.GetString("metrics.namespace"),
+			Subsystem: viper.GetString("metrics.subsystem"),
+			Name:      "shed_requests",
+			Help:      "Number of searches refused for too many being in flight.",
+		}, []string{})
+		shedLoad, err := core.NewConcurrencyLimitMiddleware(maxInFlight, inflight, shed)
+		if err != nil {
+			panic(fmt.Errorf("fatal error config concurrency: %w", err))
+		}
+		endpoints.SearchEndpoint = shedLoad(endpoints.SearchEndpoint)
+		endpoints.SearchStreamEndpoint = shedLoad(endpoints.SearchStreamEndpoint)
+	}
+	searchQueryHandler := core.NewHTTPHandler(endpoints, readiness)
+
+	httpAddress := viper.GetString("http.address")
+	grpcAddress := viper.GetString("grpc.address")
+	// The servers share one drain deadline, set when the first of them is
+	// told to stop.
+	var drainOnce sync.Once
+	var drainBy time.Time
+	drainDeadline := func() time.Time {
+		drainOnce.Do(func() { drainBy = time.Now().Add(viper.GetDuration("shutdown.drainTimeout")) })
+		return drainBy
+	}
+
+	var g group.Group
+	{
+		httpListener, err := net.Listen("tcp", httpAddress)
+		if err != nil {
+			logger.Log("transport", "HTTP", "during", "Listen", "err", err)
+			os.Exit(1)
+		}
+		httpServer := &http.Server{
+			Handler:           searchQueryHandler,
+			ReadTimeout:       viper.GetDuration("http.readTimeout"),
+			ReadHeaderTimeout: viper.GetDuration("http.readTimeout"),
+			WriteTimeout:      viper.GetDuration("http.writeTimeout"),
+			IdleTimeout:       viper.GetDuration("http.idleTimeout"),
+		}
+		g.Add(func() error {
+			logger.Log("transport", "HTTP", "addr", httpAddress)
+			if err := httpServer.Serve(httpListener); err != http.ErrServerClosed {
+				return err
+			}
+			return nil
+		}, func(error) {
+			ctx, cancel := context.WithDeadline(context.Background(), drainDeadline())
+			defer cancel()
+			if err := httpServer.Shutdown(ctx); err != nil {
+				logger.Log("transport", "HTTP", "during", "Shutdown", "err", err)
+				httpServer.Close()
+			}
+		})
+	}
+	{
+		grpcListener, err := net.Listen("tcp", grpcAddress)
+		if err != nil {
+			logger.Log("transport", "gRPC", "during", "Listen", "err", err)
+			os.Exit(1)
+		}
+		baseServer := grpc.NewServer(grpc.UnaryInterceptor(kitgrpc.Interceptor))
+		corepb.RegisterCoreServer(baseServer, core.NewGRPCServer(endpoints))
+		healthpb.RegisterHealthServer(baseServer, readiness.HealthServer())
+		g.Add(func() error {
+			logger.Log("transport", "gRPC", "addr", grpcAddress)
+			return baseServer.Serve(grpcListener)
+		}, func(error) {
+			stopGRPC(baseServer, drainDeadline(), logger)
+		})
+	}
+	if instances != nil {
+		grpcListener, err := net.Listen("tcp", registryAddress)
+		if err != nil {
+			logger.Log("transport", "gRPC", "during", "Listen", "err", err)
+			os.Exit(1)
+		}
+		baseServer := grpc.NewServer(grpc.UnaryInterceptor(kitgrpc.Interceptor))
+		registrypb.RegisterRegistryServer(baseServer, registry.NewGRPCServer(registry.NewEndpointSet(instances)))
+		g.Add(func() error {
+			logger.Log("transport", "gRPC", "addr", registryAddress)
+			return baseServer.Serve(grpcListener)
+		}, func(error) {
+			stopGRPC(baseServer, drainDeadline(), logger)
+		})
+	}
+	{
+		// The core becomes ready once its sources are connected and the
+		// cache is warm.
+		ctx, cancel := context.WithCancel(context.Background())
+		g.Add(func() error {
+			if err := sources.WaitConnected(ctx, viper.GetDuration("startup.timeout")); err == nil {
+				if cache != nil {
+					cache.Warm(ctx, viper.GetStringSlice("cache.warm"))
+				}
+				readiness.SetReady()
+				logger.Log("readiness", "ready")
+			}
+			<-ctx.Done()
+			return nil
+		}, func(error) {
+			cancel()
+		})
+	}
+	{
+		cancelInterrupt := make(chan struct{})
+		g.Add(func() error {
+			c := make(chan os.Signal, 1)
+			signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
+			select {
+			case sig := <-c:
+				// Stop being ready, and keep serving for shutdown.delay so
+				// that load balancers notice before the servers drain.
+				readiness.Drain()
+				logger.Log("signal", sig, "draining_after", viper.GetDuration("shutdown.delay"))
+				time.Sleep(viper.GetDuration("shutdown.delay"))
+				return fmt.Errorf("received signal %s", sig)
+			case <-cancelInterrupt:
+				return nil
+			}
+		}, func(error) {
+			close(cancelInterrupt)
+		})
+	}
+	logger.Log("exit", g.Run())
+}
+
+// sourceOverrides sets the field of a source named by the last part of a
+// CORE_SOURCES_<NAME>_<FIELD> environment variable, as UnmarshalKey does not
+// see environment variables for the entries of a list.
+var sourceOverrides = map[string]func(c *core.SourceConfig, value string) error{
+	"ADDRESS": func(c *core.SourceConfig, value string) error {
+		c.Address = value
+		return validateAddresses(value)
+	},
+	"ADDRESSES": func(c *core.SourceConfig, value string) error {
+		c.Addresses = nil
+		for _, address := range strings.Split(value, ",") {
+			if address = strings.TrimSpace(address); address != "" {
+				c.Addresses = append(c.Addresses, address)
+			}
+		}
+		return validateAddresses(c.Addresses...)
+	},
+	"DISCOVERYFILE": func(c *core.SourceConfig, value string) error {
+		c.DiscoveryFile = value
+		return nil
+	},
+	"BALANCER": func(c *core.SourceConfig, value string) error {
+		c.Balancer = value
+		return nil
+	},
+	"TIMEOUT":     durationOverride(func(c *core.SourceConfig) *time.Duration { return &c.Timeout }),
+	"COOLOFF":     durationOverride(func(c *core.SourceConfig) *time.Duration { return &c.CoolOff }),
+	"DIALTIMEOUT": durationOverride(func(c *core.SourceConfig) *time.Duration { return &c.DialTimeout }),
+	"RETRIES":     intOverride(func(c *core.SourceConfig) *int { return &c.Retries }),
+	"EJECTAFTER":  intOverride(func(c *core.SourceConfig) *int { return &c.EjectAfter }),
+	"RESULTLIMIT": intOverride(func(c *core.SourceConfig) *int { return &c.ResultLimit }),
+	"WEIGHT": func(c *core.SourceConfig, value string) (err error) {
+		c.Weight, err = strconv.ParseFloat(value, 64)
+		return err
+	},
+}
+
+func durationOverride(field func(*core.SourceConfig) *time.Duration) func(*core.SourceConfig, string) error {
+	return func(c *core.SourceConfig, value string) (err error) {
+		*field(c), err = time.ParseDuration(value)
+		return err
+	}
+}
+
+func intOverride(field func(*core.SourceConfig) *int) func(*core.SourceConfig, string) error {
+	return func(c *core.SourceConfig, value string) (err error) {
+		*field(c), err = strconv.Atoi(value)
+		return err
+	}
+}
+
+// overrideSources applies the CORE_SOURCES_<NAME>_<FIELD> environment
+// variables to the sources of configs/core, <NAME> being the source's name
+// upper-cased with anything but letters and digits replaced by "_". The
+// values are then validated with the rest of the source.
+func overrideSources(configs []core.SourceConfig) error {
+	for i := range configs {
+		prefix := "CORE_SOURCES_" + envName(configs[i].Name) + "_"
+		for field, override := range sourceOverrides {
+			value, ok := os.LookupEnv(prefix + field)
+			if !ok {
+				continue
+			}
+			if err := override(&configs[i], value); err != nil {
+				return fmt.Errorf("%s%s: %w", prefix, field, err)
+			}
+		}
+	}
+	return nil
+}
+
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+func validateAddresses(addresses ...string) error {
+	for _, address := range addresses {
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stopGRPC lets the server finish its in-flight calls until deadline, then
+// cuts the rest off.
+func stopGRPC(server *grpc.Server, deadline time.Time, logger log.Logger) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Until(deadline)):
+		logger.Log("transport", "gRPC", "during", "GracefulStop", "err", "drain deadline exceeded")
+		server.Stop()
+	}
+}
+
+var metricName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// validateConfig checks the settings main reads directly. The sources and
+// the search defaults are checked when the registry and service are built.
+func validateConfig() error {
+	addresses := map[string]string{}
+	for _, key := range []string{"http.address", "grpc.address", "registry.address"} {
+		address := viper.GetString(key)
+		if address == "" && key == "registry.address" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		if other, ok := addresses[address]; ok {
+			return fmt.Errorf("%s and %s are both %s", other, key, address)
+		}
+		addresses[address] = key
+	}
+
+	if !metricName.MatchString(viper.GetString("metrics.namespace")) {
+		return fmt.Errorf("metrics.namespace %q is not a valid metric name", viper.GetString("metrics.namespace"))
+	}
+	if subsystem := viper.GetString("metrics.subsystem"); subsystem != "" && !metricName.MatchString(subsystem) {
+		return fmt.Errorf("metrics.subsystem %q is not a valid metric name", subsystem)
+	}
 
-	httpListener, _ := net.Listen("tcp", "localhost:8080")
-	http.Serve(httpListener, searchQueryHandler)
+	durations := []string{"http.readTimeout", "http.writeTimeout", "http.idleTimeout", "shutdown.drainTimeout", "status.timeout", "startup.timeout"}
+	if viper.GetInt("cache.size") > 0 {
+		durations = append(durations, "cache.ttl")
+	}
+	if viper.GetString("registry.address") != "" {
+		durations = append(durations, "registry.ttl")
+	}
+	for _, key := range durations {
+		if viper.GetDuration(key) <= 0 {
+			return fmt.Errorf("%s must be a positive duration, got %q", key, viper.GetString(key))
+		}
+	}
+	if viper.GetDuration("shutdown.delay") < 0 {
+		return errors.New("shutdown.delay must not be negative")
+	}
+	if viper.GetFloat64("rateLimit.perSecond") < 0 {
+		return errors.New("rateLimit.perSecond must not be negative")
+	}
+	if viper.GetFloat64("rateLimit.perSecond") > 0 && viper.GetInt("rateLimit.burst") <= 0 {
+		return errors.New("rateLimit.burst must be positive")
+	}
+	if viper.GetFloat64("rateLimit.address.perSecond") < 0 {
+		return errors.New("rateLimit.address.perSecond must not be negative")
+	}
+	if viper.GetFloat64("rateLimit.address.perSecond") > 0 && viper.GetInt("rateLimit.address.burst") <= 0 {
+		return errors.New("rateLimit.address.burst must be positive")
+	}
+	if viper.GetInt("concurrency.maxInFlight") < 0 {
+		return errors.New("concurrency.maxInFlight must not be negative")
+	}
+	if viper.GetInt("cache.size") < 0 {
+		return errors.New("cache.size must not be negative")
+	}
+	if !viper.IsSet("sources") && viper.GetString("registry.address") == "" {
+		return errors.New("no sources configured and no registry.address for instances to register with")
+	}
+	return nil
 }
@@ -9,7 +9,18 @@ import (
 )
 
 type albumSearchRequest struct {
-	Query string
+	Query  string
+	Offset int
+	Limit  int
+}
+
+// albumSearchStreamRequest asks for the results of a search to be passed to
+// Send as they are found.
+type albumSearchStreamRequest struct {
+	Query  string
+	Offset int
+	Limit  int
+	Send   func([]Album) error
 }
 
 type Album struct {
@@ -22,6 +33,12 @@ type albumSearchResponse struct {
 	Err    string
 }
 
+// ServiceError is an error the album service reported in its response, as
+// opposed to a failure to reach the service.
+type ServiceError string
+
+func (e ServiceError) Error() string { return string(e) }
+
 type serviceStatusRequest struct{}
 
 type serviceStatusResponse struct {
@@ -31,38 +48,58 @@ type serviceStatusResponse struct {
 
 type Set struct {
 	SearchEndpoint        endpoint.Endpoint
+	SearchStreamEndpoint  endpoint.Endpoint
 	ServiceStatusEndpoint endpoint.Endpoint
 }
 
 func NewEndpointSet(service AlbumService) Set {
 	return Set{
 		SearchEndpoint:        makeAlbumSearchEndpoint(service),
+		SearchStreamEndpoint:  makeAlbumSearchStreamEndpoint(service),
 		ServiceStatusEndpoint: makeServiceStatusEndpoint(service),
 	}
 }
 
-func (s Set) Find(ctx context.Context, query string) ([]Album, error) {
-	resp, err := s.SearchEndpoint(ctx, albumSearchRequest{Query: query})
+func (s Set) Find(ctx context.Context, query string, offset int, limit int) ([]Album, error) {
+	resp, err := s.SearchEndpoint(ctx, albumSearchRequest{Query: query, Offset: offset, Limit: limit})
 	if err != nil {
-		return []Album{}, errEmpty
+		return []Album{}, err
 	}
 	response := resp.(*albumSearchResponse)
+	if response.Err != "" {
+		return []Album{}, ServiceError(response.Err)
+	}
 	return response.Albums, nil
 }
 
+func (s Set) FindStream(ctx context.Context, query string, offset int, limit int, send func([]Album) error) error {
+	resp, err := s.SearchStreamEndpoint(ctx, albumSearchStreamRequest{Query: query, Offset: offset, Limit: limit, Send: send})
+	if err != nil {
+		return err
+	}
+	response := resp.(*albumSearchResponse)
+	if response.Err != "" {
+		return ServiceError(response.Err)
+	}
+	return nil
+}
+
 func (s Set) ServiceStatus(ctx context.Context) (int, error) {
 	resp, err := s.ServiceStatusEndpoint(ctx, serviceStatusRequest{})
 	if err != nil {
 		return http.StatusNotFound, err
 	}
 	response := resp.(*serviceStatusResponse)
+	if response.Err != "" {
+		return response.Status, ServiceError(response.Err)
+	}
 	return response.Status, nil
 }
 
 func makeAlbumSearchEndpoint(service AlbumService) endpoint.Endpoint {
 	return func(c context.Context, request interface{}) (interface{}, error) {
 		req := request.(*albumSearchRequest)
-		searchResult, err := service.Find(c, req.Query)
+		searchResult, err := service.Find(c, req.Query, req.Offset, req.Limit)
 		if err != nil {
 			return albumSearchResponse{Albums: []Album{}, Err: err.Error()}, nil
 		}
@@ -70,6 +107,16 @@ func makeAlbumSearchEndpoint(service AlbumService) endpoint.Endpoint {
 	}
 }
 
+func makeAlbumSearchStreamEndpoint(service AlbumService) endpoint.Endpoint {
+	return func(c context.Context, request interface{}) (interface{}, error) {
+		req := request.(albumSearchStreamRequest)
+		if err := service.FindStream(c, req.Query, req.Offset, req.Limit, req.Send); err != nil {
+			return &albumSearchResponse{Albums: []Album{}, Err: err.Error()}, nil
+		}
+		return &albumSearchResponse{Albums: []Album{}, Err: ""}, nil
+	}
+}
+
 func makeServiceStatusEndpoint(service AlbumService) endpoint.Endpoint {
 	return func(c context.Context, request interface{}) (interface{}, error) {
 		req := request.(*serviceStatusRequest)
@@ -0,0 +1,135 @@
+package album
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// healthServiceName is the name the readiness of the album service is
+// reported under, besides the empty name standing for the whole server.
+// Liveness is reported under livenessServiceName.
+const (
+	healthServiceName   = "album"
+	livenessServiceName = "liveness"
+)
+
+var (
+	errShuttingDown = errors.New("album service is shutting down")
+	errNotChecked   = errors.New("album service has not been checked yet")
+)
+
+// HealthChecker checks Next every interval of Run, and answers ServiceStatus
+// with the result of the last check rather than checking again. The
+// grpc.health.v1 status of the server follows the checks. Once Shutdown is
+// called the server stays NOT_SERVING and ServiceStatus reports 503.
+// Liveness stays SERVING for as long as the server runs.
+//
+// Next should be the bare service, so that the periodic checks are neither
+// logged nor counted as requests.
+type HealthChecker struct {
+	Next AlbumService
+
+	server  *health.Server
+	timeout time.Duration
+
+	mtx      sync.Mutex
+	shutdown bool
+	status   int
+	err      error
+	serving  chan struct{}
+}
+
+// NewHealthChecker returns a checker of next whose checks give up after
+// timeout. The server is NOT_SERVING until the first check passes.
+func NewHealthChecker(timeout time.Duration, next AlbumService) *HealthChecker {
+	h := &HealthChecker{Next: next, server: health.NewServer(), timeout: timeout, status: http.StatusServiceUnavailable, err: errNotChecked, serving: make(chan struct{})}
+	h.server.SetServingStatus(livenessServiceName, healthpb.HealthCheckResponse_SERVING)
+	h.setServing(false)
+	return h
+}
+
+// Server returns the health service to register on the gRPC server.
+func (h *HealthChecker) Server() healthpb.HealthServer {
+	return h.server
+}
+
+// Serving returns a channel closed once a check has passed for the first
+// time.
+func (h *HealthChecker) Serving() <-chan struct{} {
+	return h.serving
+}
+
+// Run checks the service every interval until stop is closed.
+func (h *HealthChecker) Run(interval time.Duration, stop <-chan struct{}) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		h.check()
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return nil
+		}
+	}
+}
+
+// Shutdown marks the server NOT_SERVING for good, so that no new traffic is
+// routed to it while it drains.
+func (h *HealthChecker) Shutdown() {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.setServing(false)
+	h.shutdown = true
+}
+
+func (h *HealthChecker) Find(c context.Context, query string, offset int, limit int) ([]Album, error) {
+	return h.Next.Find(c, query, offset, limit)
+}
+
+func (h *HealthChecker) FindStream(c context.Context, query string, offset int, limit int, send func([]Album) error) error {
+	return h.Next.FindStream(c, query, offset, limit, send)
+}
+
+// ServiceStatus answers with the result of the last check.
+func (h *HealthChecker) ServiceStatus(context.Context) (int, error) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if h.shutdown {
+		return http.StatusServiceUnavailable, errShuttingDown
+	}
+	return h.status, h.err
+}
+
+func (h *HealthChecker) check() {
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+	status, err := h.Next.ServiceStatus(ctx)
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.status, h.err = status, err
+	h.setServing(err == nil && status == http.StatusOK)
+}
+
+// setServing must be called with mtx held, so that it cannot undo Shutdown.
+func (h *HealthChecker) setServing(serving bool) {
+	if h.shutdown {
+		return
+	}
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if serving {
+		status = healthpb.HealthCheckResponse_SERVING
+		select {
+		case <-h.serving:
+		default:
+			close(h.serving)
+		}
+	}
+	h.server.SetServingStatus("", status)
+	h.server.SetServingStatus(healthServiceName, status)
+}
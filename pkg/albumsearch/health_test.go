@@ -0,0 +1,62 @@
+package album
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// healthyService passes every check.
+type healthyService struct {
+	AlbumService
+}
+
+func (healthyService) ServiceStatus(context.Context) (int, error) {
+	return http.StatusOK, nil
+}
+
+func TestHealthCheckerShutdownWinsOverCheck(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		h := NewHealthChecker(time.Second, healthyService{})
+		stop := make(chan struct{})
+		var started, wg sync.WaitGroup
+		for j := 0; j < 4; j++ {
+			started.Add(1)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				h.check()
+				started.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+						h.check()
+					}
+				}
+			}()
+		}
+		started.Wait()
+		h.Shutdown()
+		close(stop)
+		wg.Wait()
+
+		for _, service := range []string{"", healthServiceName} {
+			resp, err := h.Server().Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+			if err != nil {
+				t.Fatalf("Check(%q): %v", service, err)
+			}
+			if resp.Status != healthpb.HealthCheckResponse_NOT_SERVING {
+				t.Fatalf("Check(%q) after Shutdown = %v, want NOT_SERVING", service, resp.Status)
+			}
+		}
+		if status, _ := h.ServiceStatus(context.Background()); status != http.StatusServiceUnavailable {
+			t.Fatalf("ServiceStatus after Shutdown = %d, want 503", status)
+		}
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"time"
 
+	"microservices-with-go/pkg/requestid"
+
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/metrics"
 )
@@ -15,7 +17,7 @@ type LoggingMiddleware struct {
 	Next   AlbumService
 }
 
-func (mw LoggingMiddleware) Find(c context.Context, s string) (output []Album, err error) {
+func (mw LoggingMiddleware) Find(c context.Context, s string, offset int, limit int) (output []Album, err error) {
 	defer func(begin time.Time) {
 
 		jsonData, err := json.Marshal(&output)
@@ -27,27 +29,54 @@ func (mw LoggingMiddleware) Find(c context.Context, s string) (output []Album, e
 
 		_ = mw.Logger.Log(
 			"method", "findAlbumRequest",
+			"request_id", requestid.FromContext(c),
 			"input", s,
+			"offset", offset,
+			"limit", limit,
 			"output", printableOutput,
 			"err", err,
 			"duration", time.Since(begin),
 		)
 	}(time.Now())
 
-	output, err = mw.Next.Find(c, s)
+	output, err = mw.Next.Find(c, s, offset, limit)
+	return
+}
+
+func (mw LoggingMiddleware) FindStream(c context.Context, s string, offset int, limit int, send func([]Album) error) (err error) {
+	sent := 0
+	defer func(begin time.Time) {
+		_ = mw.Logger.Log(
+			"method", "findAlbumStreamRequest",
+			"request_id", requestid.FromContext(c),
+			"input", s,
+			"offset", offset,
+			"limit", limit,
+			"sent", sent,
+			"err", err,
+			"duration", time.Since(begin),
+		)
+	}(time.Now())
+
+	err = mw.Next.FindStream(c, s, offset, limit, func(albums []Album) error {
+		sent += len(albums)
+		return send(albums)
+	})
 	return
 }
 
-func (mw LoggingMiddleware) ServiceStatus(_ context.Context) (output int, err error) {
+func (mw LoggingMiddleware) ServiceStatus(c context.Context) (output int, err error) {
 	defer func(begin time.Time) {
 		_ = mw.Logger.Log(
 			"method", "userQueryServiceStatus",
+			"request_id", requestid.FromContext(c),
 			"output", output,
 			"err", err,
 			"duration", time.Since(begin),
 		)
 	}(time.Now())
 
+	output, err = mw.Next.ServiceStatus(c)
 	return
 }
 
@@ -57,23 +86,35 @@ type InstrumentingMiddleware struct {
 	Next           AlbumService
 }
 
-func (mw InstrumentingMiddleware) Find(c context.Context, s string) (output []Album, err error) {
+func (mw InstrumentingMiddleware) Find(c context.Context, s string, offset int, limit int) (output []Album, err error) {
 	defer func(begin time.Time) {
 		lvs := []string{"method", "search", "error", fmt.Sprint(err != nil)}
 		mw.RequestCount.With(lvs...).Add(1)
 		mw.RequestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	output, err = mw.Next.Find(c, s)
+	output, err = mw.Next.Find(c, s, offset, limit)
+	return
+}
+
+func (mw InstrumentingMiddleware) FindStream(c context.Context, s string, offset int, limit int, send func([]Album) error) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "searchstream", "error", fmt.Sprint(err != nil)}
+		mw.RequestCount.With(lvs...).Add(1)
+		mw.RequestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.Next.FindStream(c, s, offset, limit, send)
 	return
 }
 
-func (mw InstrumentingMiddleware) ServiceStatus(_ context.Context) (output int, err error) {
+func (mw InstrumentingMiddleware) ServiceStatus(c context.Context) (output int, err error) {
 	defer func(begin time.Time) {
 		lvs := []string{"method", "searchservicestatus", "error", fmt.Sprint(err != nil)}
 		mw.RequestCount.With(lvs...).Add(1)
 		mw.RequestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
+	output, err = mw.Next.ServiceStatus(c)
 	return
 }
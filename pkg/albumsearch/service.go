@@ -7,19 +7,30 @@ import (
 	"io/ioutil"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 
+	"github.com/sony/gobreaker"
 	"github.com/spf13/viper"
 )
 
 type AlbumService interface {
-	Find(context.Context, string) ([]Album, error)
+	Find(ctx context.Context, query string, offset int, limit int) ([]Album, error)
+	FindStream(ctx context.Context, query string, offset int, limit int, send func([]Album) error) error
 	ServiceStatus(context.Context) (int, error)
 }
 
-type findAlbumService struct{}
+// findAlbumService calls the iTunes Search API through a circuit breaker, which
+// stops calling it for a while after repeated failures.
+type findAlbumService struct {
+	breaker *gobreaker.CircuitBreaker
+}
 
-func NewService() AlbumService { return &findAlbumService{} }
+func NewService() AlbumService {
+	return &findAlbumService{
+		breaker: gobreaker.NewCircuitBreaker(gobreaker.Settings{Name: "iTunes Search API"}),
+	}
+}
 
 type ItunesResponse struct {
 	ResultCount int `json:"resultCount"`
@@ -29,11 +40,75 @@ type ItunesResponse struct {
 	} `json:"results"`
 }
 
-func (s *findAlbumService) Find(_ context.Context, query string) ([]Album, error) {
+// Find returns up to limit results starting at offset. A limit of 0 or one
+// above resultLimit means resultLimit.
+func (s *findAlbumService) Find(ctx context.Context, query string, offset int, limit int) ([]Album, error) {
 	if query == "" {
 		return []Album{}, errEmpty
 	}
+	offset, limit = pageBounds(offset, limit)
+	return s.fetch(ctx, query, offset, limit)
+}
+
+// FindStream returns the results Find would, passing them to send as each
+// upstream page of up to streamPageSize results is fetched.
+func (s *findAlbumService) FindStream(ctx context.Context, query string, offset int, limit int, send func([]Album) error) error {
+	if query == "" {
+		return errEmpty
+	}
+	offset, limit = pageBounds(offset, limit)
+	pageSize := viper.GetInt("streamPageSize")
+	if pageSize <= 0 || pageSize > limit {
+		pageSize = limit
+	}
+
+	for fetched := 0; fetched < limit; {
+		size := pageSize
+		if limit-fetched < size {
+			size = limit - fetched
+		}
+		albums, err := s.fetch(ctx, query, offset+fetched, size)
+		if err != nil {
+			return err
+		}
+		if len(albums) > 0 {
+			if err := send(albums); err != nil {
+				return err
+			}
+		}
+		if len(albums) < size {
+			return nil
+		}
+		fetched += len(albums)
+	}
+	return nil
+}
+
+func pageBounds(offset int, limit int) (int, int) {
+	if limit <= 0 || limit > viper.GetInt("resultLimit") {
+		limit = viper.GetInt("resultLimit")
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return offset, limit
+}
+
+// fetch calls the iTunes Search API for one page of results.
+func (s *findAlbumService) fetch(ctx context.Context, query string, offset int, limit int) ([]Album, error) {
+	result, err := s.breaker.Execute(func() (interface{}, error) {
+		return fetchPage(ctx, query, offset, limit)
+	})
+	if err != nil {
+		if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+			return []Album{}, errUpstream
+		}
+		return []Album{}, err
+	}
+	return result.([]Album), nil
+}
 
+func fetchPage(ctx context.Context, query string, offset int, limit int) ([]Album, error) {
 	re, err := regexp.Compile(`[^\w]`)
 	if err != nil {
 		logger.Log("Failed to parse user input\n")
@@ -45,27 +120,37 @@ func (s *findAlbumService) Find(_ context.Context, query string) ([]Album, error
 	urlBuilder.WriteString(viper.GetString("apiEndpoint"))
 	urlBuilder.WriteString("term=")
 	urlBuilder.WriteString(cleanInput)
-	urlBuilder.WriteString("&entity=album&limit=")
-	urlBuilder.WriteString(viper.GetString("resultLimit"))
+	urlBuilder.WriteString("&entity=album&offset=")
+	urlBuilder.WriteString(strconv.Itoa(offset))
+	urlBuilder.WriteString("&limit=")
+	urlBuilder.WriteString(strconv.Itoa(limit))
 
 	url := urlBuilder.String()
 	logger.Log("Url: ", url)
-	resp, err := http.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return []Album{}, err
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		logger.Log("Failed to fetch results from iTunes Search API\n")
-		return []Album{}, errEmpty
+		return []Album{}, errUpstream
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		logger.Log("iTunes Search API answered with status", resp.StatusCode)
+		return []Album{}, errUpstream
+	}
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		logger.Log("Failed to read from iTunes Search API response\n")
-		return []Album{}, errEmpty
+		return []Album{}, errUpstream
 	}
 
 	var getResult ItunesResponse
 	if err := json.Unmarshal(body, &getResult); err != nil {
 		logger.Log("Failed to unmashal iTunes Search API response\n")
-		return []Album{}, errEmpty
+		return []Album{}, errUpstream
 	}
 	var albums []Album
 
@@ -78,8 +163,27 @@ func (s *findAlbumService) Find(_ context.Context, query string) ([]Album, error
 	return albums, nil
 }
 
-func (s *findAlbumService) ServiceStatus(_ context.Context) (int, error) {
+// ServiceStatus reports 503 while the breaker is open or the iTunes Search API
+// cannot be reached, and 200 otherwise.
+func (s *findAlbumService) ServiceStatus(ctx context.Context) (int, error) {
+	if s.breaker.State() == gobreaker.StateOpen {
+		return http.StatusServiceUnavailable, errUpstream
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, viper.GetString("apiEndpoint"), nil)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return http.StatusServiceUnavailable, errUpstream
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return http.StatusServiceUnavailable, errUpstream
+	}
 	return http.StatusOK, nil
 }
 
 var errEmpty = errors.New("Query is empty")
+
+var errUpstream = errors.New("iTunes Search API is unavailable")
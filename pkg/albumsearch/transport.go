@@ -3,7 +3,9 @@ package album
 import (
 	"context"
 	"fmt"
+	"io"
 	album "microservices-with-go/api/album"
+	"microservices-with-go/pkg/requestid"
 	"os"
 	"time"
 
@@ -19,6 +21,7 @@ import (
 
 type grpcServer struct {
 	find          grpctransport.Handler
+	findStream    endpoint.Endpoint
 	serviceStatus grpctransport.Handler
 	album.UnimplementedAlbumServer
 }
@@ -29,11 +32,14 @@ func NewGRPCServer(endpoints Set) album.AlbumServer {
 			endpoints.SearchEndpoint,
 			decodeGRPCFindAlbumRequest,
 			encodeGRPCFindAlbumResponse,
+			grpctransport.ServerBefore(requestid.GRPCToContext()),
 		),
+		findStream: endpoints.SearchStreamEndpoint,
 		serviceStatus: grpctransport.NewServer(
 			endpoints.ServiceStatusEndpoint,
 			decodeGRPCServiceStatusRequest,
 			encodeGRPCServiceStatusResponse,
+			grpctransport.ServerBefore(requestid.GRPCToContext()),
 		),
 	}
 }
@@ -47,6 +53,29 @@ func (g *grpcServer) Find(ctx context.Context, r *album.FindAlbumRequest) (*albu
 	return rep.(*album.FindAlbumResponse), nil
 }
 
+// FindStream sends the results of the search as the service finds them. An
+// error the service reports is sent as a last message carrying only Err, as
+// Find does.
+func (g *grpcServer) FindStream(r *album.FindAlbumRequest, stream album.Album_FindStreamServer) error {
+	logger.Log("Album transport", "FindStream")
+	request := albumSearchStreamRequest{
+		Query:  r.Query,
+		Offset: int(r.Offset),
+		Limit:  int(r.Limit),
+		Send: func(albums []Album) error {
+			return stream.Send(&album.FindAlbumResponse{Albums: localAlbumToPbAlbum(albums)})
+		},
+	}
+	rep, err := g.findStream(requestid.IncomingContext(stream.Context()), request)
+	if err != nil {
+		return err
+	}
+	if reply := rep.(*albumSearchResponse); reply.Err != "" {
+		return stream.Send(&album.FindAlbumResponse{Err: reply.Err})
+	}
+	return nil
+}
+
 func (g *grpcServer) ServiceStatus(ctx context.Context, r *album.AlbumServiceStatusRequest) (*album.AlbumServiceStatusResponse, error) {
 	_, rep, err := g.serviceStatus.ServeGRPC(ctx, r)
 	if err != nil {
@@ -62,7 +91,7 @@ func (g *grpcServer) mustEmbedUnimplementedAlbumServer() {
 func decodeGRPCFindAlbumRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
 	req := grpcReq.(*album.FindAlbumRequest)
 	logger.Log("Decoding FindAlbumRequest for: ", req.Query)
-	return &albumSearchRequest{Query: req.Query}, nil
+	return &albumSearchRequest{Query: req.Query, Offset: int(req.Offset), Limit: int(req.Limit)}, nil
 }
 
 func decodeGRPCServiceStatusRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
@@ -73,7 +102,7 @@ func decodeGRPCServiceStatusRequest(_ context.Context, grpcReq interface{}) (int
 
 func encodeGRPCFindAlbumResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
 	reply := grpcReply.(albumSearchResponse)
-	logger.Log("Encoding FindAlbumResponse for: ", reply.Albums[0].Title)
+	logger.Log("Encoding FindAlbumResponse, albums: ", len(reply.Albums))
 	return &album.FindAlbumResponse{Albums: localAlbumToPbAlbum(reply.Albums), Err: reply.Err}, nil
 }
 
@@ -103,6 +132,7 @@ func NewGRPCClient(conn *grpc.ClientConn) AlbumService {
 			encodeGRPCFindAlbumRequest,
 			decodeGRPCFindAlbumResponse,
 			album.FindAlbumResponse{},
+			grpctransport.ClientBefore(requestid.ContextToGRPC()),
 		).Endpoint()
 		findAlbumEndpoint = limiter(findAlbumEndpoint)
 		findAlbumEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
@@ -111,6 +141,16 @@ func NewGRPCClient(conn *grpc.ClientConn) AlbumService {
 		}))(findAlbumEndpoint)
 	}
 
+	var findAlbumStreamEndpoint endpoint.Endpoint
+	{
+		findAlbumStreamEndpoint = makeGRPCFindStreamEndpoint(album.NewAlbumClient(conn))
+		findAlbumStreamEndpoint = limiter(findAlbumStreamEndpoint)
+		findAlbumStreamEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "FindStream",
+			Timeout: 10 * time.Second,
+		}))(findAlbumStreamEndpoint)
+	}
+
 	var albumServiceStatusEndpoint endpoint.Endpoint
 	{
 		albumServiceStatusEndpoint = grpctransport.NewClient(
@@ -120,6 +160,7 @@ func NewGRPCClient(conn *grpc.ClientConn) AlbumService {
 			encodeGRPCServiceStatusRequest,
 			decodeGRPCServiceStatusResponse,
 			album.AlbumServiceStatusResponse{},
+			grpctransport.ClientBefore(requestid.ContextToGRPC()),
 		).Endpoint()
 		albumServiceStatusEndpoint = limiter(albumServiceStatusEndpoint)
 		albumServiceStatusEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
@@ -130,6 +171,7 @@ func NewGRPCClient(conn *grpc.ClientConn) AlbumService {
 
 	return Set{
 		SearchEndpoint:        findAlbumEndpoint,
+		SearchStreamEndpoint:  findAlbumStreamEndpoint,
 		ServiceStatusEndpoint: albumServiceStatusEndpoint,
 	}
 }
@@ -137,7 +179,35 @@ func NewGRPCClient(conn *grpc.ClientConn) AlbumService {
 func encodeGRPCFindAlbumRequest(_ context.Context, request interface{}) (interface{}, error) {
 	req := request.(albumSearchRequest)
 	logger.Log("Encoding FindAlbumRequest for: ", req.Query)
-	return &album.FindAlbumRequest{Query: req.Query}, nil
+	return &album.FindAlbumRequest{Query: req.Query, Offset: int32(req.Offset), Limit: int32(req.Limit)}, nil
+}
+
+// makeGRPCFindStreamEndpoint returns an endpoint calling FindStream, which
+// grpctransport.Client cannot do as it only supports unary calls. It passes
+// each message's albums to the request's Send.
+func makeGRPCFindStreamEndpoint(client album.AlbumClient) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(albumSearchStreamRequest)
+		stream, err := client.FindStream(requestid.OutgoingContext(ctx), &album.FindAlbumRequest{Query: req.Query, Offset: int32(req.Offset), Limit: int32(req.Limit)})
+		if err != nil {
+			return nil, err
+		}
+		for {
+			reply, err := stream.Recv()
+			if err == io.EOF {
+				return &albumSearchResponse{Albums: []Album{}}, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			if reply.Err != "" {
+				return &albumSearchResponse{Albums: []Album{}, Err: reply.Err}, nil
+			}
+			if err := req.Send(pbAlbumToLocalAlbum(reply.Albums)); err != nil {
+				return nil, err
+			}
+		}
+	}
 }
 
 func encodeGRPCServiceStatusRequest(_ context.Context, request interface{}) (interface{}, error) {
@@ -147,8 +217,8 @@ func encodeGRPCServiceStatusRequest(_ context.Context, request interface{}) (int
 
 func decodeGRPCFindAlbumResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
 	req := grpcRes.(*album.FindAlbumResponse)
-	logger.Log("Decoding FindAlbumResponse for: ", req.Albums[0].Title)
-	return &albumSearchResponse{Albums: pbAlbumToLocalAlbum(req.Albums)}, nil
+	logger.Log("Decoding FindAlbumResponse, albums: ", len(req.Albums))
+	return &albumSearchResponse{Albums: pbAlbumToLocalAlbum(req.Albums), Err: req.Err}, nil
 }
 
 func pbAlbumToLocalAlbum(pbAlbums []*album.Album) []Album {
@@ -163,7 +233,7 @@ func pbAlbumToLocalAlbum(pbAlbums []*album.Album) []Album {
 func decodeGRPCServiceStatusResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
 	req := grpcRes.(*album.AlbumServiceStatusResponse)
 	logger.Log("Decoding ServiceStatusResponse for: ", req.Code)
-	return &serviceStatusResponse{Status: int(req.Code)}, nil
+	return &serviceStatusResponse{Status: int(req.Code), Err: req.Err}, nil
 }
 
 var logger log.Logger
@@ -9,7 +9,18 @@ import (
 )
 
 type bookSearchRequest struct {
-	Query string
+	Query  string
+	Offset int
+	Limit  int
+}
+
+// bookSearchStreamRequest asks for the results of a search to be passed to
+// Send as they are found.
+type bookSearchStreamRequest struct {
+	Query  string
+	Offset int
+	Limit  int
+	Send   func([]Book) error
 }
 
 type Book struct {
@@ -22,6 +33,12 @@ type bookSearchResponse struct {
 	Err   string
 }
 
+// ServiceError is an error the book service reported in its response, as
+// opposed to a failure to reach the service.
+type ServiceError string
+
+func (e ServiceError) Error() string { return string(e) }
+
 type serviceStatusRequest struct{}
 
 type serviceStatusResponse struct {
@@ -31,38 +48,58 @@ type serviceStatusResponse struct {
 
 type Set struct {
 	SearchEndpoint        endpoint.Endpoint
+	SearchStreamEndpoint  endpoint.Endpoint
 	ServiceStatusEndpoint endpoint.Endpoint
 }
 
 func NewEndpointSet(service BookService) Set {
 	return Set{
 		SearchEndpoint:        makeBookSearchEndpoint(service),
+		SearchStreamEndpoint:  makeBookSearchStreamEndpoint(service),
 		ServiceStatusEndpoint: makeServiceStatusEndpoint(service),
 	}
 }
 
-func (s Set) Find(ctx context.Context, query string) ([]Book, error) {
-	resp, err := s.SearchEndpoint(ctx, bookSearchRequest{Query: query})
+func (s Set) Find(ctx context.Context, query string, offset int, limit int) ([]Book, error) {
+	resp, err := s.SearchEndpoint(ctx, bookSearchRequest{Query: query, Offset: offset, Limit: limit})
 	if err != nil {
-		return []Book{}, errEmpty
+		return []Book{}, err
 	}
 	response := resp.(*bookSearchResponse)
+	if response.Err != "" {
+		return []Book{}, ServiceError(response.Err)
+	}
 	return response.Books, nil
 }
 
+func (s Set) FindStream(ctx context.Context, query string, offset int, limit int, send func([]Book) error) error {
+	resp, err := s.SearchStreamEndpoint(ctx, bookSearchStreamRequest{Query: query, Offset: offset, Limit: limit, Send: send})
+	if err != nil {
+		return err
+	}
+	response := resp.(*bookSearchResponse)
+	if response.Err != "" {
+		return ServiceError(response.Err)
+	}
+	return nil
+}
+
 func (s Set) ServiceStatus(ctx context.Context) (int, error) {
 	resp, err := s.ServiceStatusEndpoint(ctx, serviceStatusRequest{})
 	if err != nil {
 		return http.StatusNotFound, err
 	}
 	response := resp.(*serviceStatusResponse)
+	if response.Err != "" {
+		return response.Status, ServiceError(response.Err)
+	}
 	return response.Status, nil
 }
 
 func makeBookSearchEndpoint(service BookService) endpoint.Endpoint {
 	return func(c context.Context, request interface{}) (interface{}, error) {
 		req := request.(*bookSearchRequest)
-		searchResult, err := service.Find(c, req.Query)
+		searchResult, err := service.Find(c, req.Query, req.Offset, req.Limit)
 		if err != nil {
 			return bookSearchResponse{Books: []Book{}, Err: err.Error()}, nil
 		}
@@ -70,6 +107,16 @@ func makeBookSearchEndpoint(service BookService) endpoint.Endpoint {
 	}
 }
 
+func makeBookSearchStreamEndpoint(service BookService) endpoint.Endpoint {
+	return func(c context.Context, request interface{}) (interface{}, error) {
+		req := request.(bookSearchStreamRequest)
+		if err := service.FindStream(c, req.Query, req.Offset, req.Limit, req.Send); err != nil {
+			return &bookSearchResponse{Books: []Book{}, Err: err.Error()}, nil
+		}
+		return &bookSearchResponse{Books: []Book{}, Err: ""}, nil
+	}
+}
+
 func makeServiceStatusEndpoint(service BookService) endpoint.Endpoint {
 	return func(c context.Context, request interface{}) (interface{}, error) {
 		req := request.(*serviceStatusRequest)
@@ -7,19 +7,30 @@ import (
 	"io/ioutil"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 
+	"github.com/sony/gobreaker"
 	"github.com/spf13/viper"
 )
 
 type BookService interface {
-	Find(context.Context, string) ([]Book, error)
+	Find(ctx context.Context, query string, offset int, limit int) ([]Book, error)
+	FindStream(ctx context.Context, query string, offset int, limit int, send func([]Book) error) error
 	ServiceStatus(context.Context) (int, error)
 }
 
-type findBookService struct{}
+// findBookService calls the Google Book Search API through a circuit
+// breaker, which stops calling it for a while after repeated failures.
+type findBookService struct {
+	breaker *gobreaker.CircuitBreaker
+}
 
-func NewService() BookService { return &findBookService{} }
+func NewService() BookService {
+	return &findBookService{
+		breaker: gobreaker.NewCircuitBreaker(gobreaker.Settings{Name: "Google Book Search API"}),
+	}
+}
 
 type GoogleResponse struct {
 	TotalItems int `json:"totalItems"`
@@ -31,11 +42,75 @@ type GoogleResponse struct {
 	} `json:"items"`
 }
 
-func (s *findBookService) Find(_ context.Context, query string) ([]Book, error) {
+// Find returns up to limit results starting at offset. A limit of 0 or one
+// above resultLimit means resultLimit.
+func (s *findBookService) Find(ctx context.Context, query string, offset int, limit int) ([]Book, error) {
 	if query == "" {
 		return []Book{}, errEmpty
 	}
+	offset, limit = pageBounds(offset, limit)
+	return s.fetch(ctx, query, offset, limit)
+}
+
+// FindStream returns the results Find would, passing them to send as each
+// upstream page of up to streamPageSize results is fetched.
+func (s *findBookService) FindStream(ctx context.Context, query string, offset int, limit int, send func([]Book) error) error {
+	if query == "" {
+		return errEmpty
+	}
+	offset, limit = pageBounds(offset, limit)
+	pageSize := viper.GetInt("streamPageSize")
+	if pageSize <= 0 || pageSize > limit {
+		pageSize = limit
+	}
+
+	for fetched := 0; fetched < limit; {
+		size := pageSize
+		if limit-fetched < size {
+			size = limit - fetched
+		}
+		books, err := s.fetch(ctx, query, offset+fetched, size)
+		if err != nil {
+			return err
+		}
+		if len(books) > 0 {
+			if err := send(books); err != nil {
+				return err
+			}
+		}
+		if len(books) < size {
+			return nil
+		}
+		fetched += len(books)
+	}
+	return nil
+}
+
+func pageBounds(offset int, limit int) (int, int) {
+	if limit <= 0 || limit > viper.GetInt("resultLimit") {
+		limit = viper.GetInt("resultLimit")
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return offset, limit
+}
+
+// fetch calls the Google Book Search API for one page of results.
+func (s *findBookService) fetch(ctx context.Context, query string, offset int, limit int) ([]Book, error) {
+	result, err := s.breaker.Execute(func() (interface{}, error) {
+		return fetchPage(ctx, query, offset, limit)
+	})
+	if err != nil {
+		if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+			return []Book{}, errUpstream
+		}
+		return []Book{}, err
+	}
+	return result.([]Book), nil
+}
 
+func fetchPage(ctx context.Context, query string, offset int, limit int) ([]Book, error) {
 	re, err := regexp.Compile(`[^\w]`)
 	if err != nil {
 		logger.Log("Failed to parse user input\n")
@@ -47,27 +122,37 @@ func (s *findBookService) Find(_ context.Context, query string) ([]Book, error)
 	urlBuilder.WriteString(viper.GetString("apiEndpoint"))
 	urlBuilder.WriteString("q=")
 	urlBuilder.WriteString(cleanInput)
+	urlBuilder.WriteString("&startIndex=")
+	urlBuilder.WriteString(strconv.Itoa(offset))
 	urlBuilder.WriteString("&maxResults=")
-	urlBuilder.WriteString(viper.GetString("resultLimit"))
+	urlBuilder.WriteString(strconv.Itoa(limit))
 
 	url := urlBuilder.String()
 	logger.Log("Url: ", url)
-	resp, err := http.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return []Book{}, err
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		logger.Log("Failed to fetch results from Google Book Search API\n")
-		return []Book{}, errEmpty
+		return []Book{}, errUpstream
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		logger.Log("Google Book Search API answered with status", resp.StatusCode)
+		return []Book{}, errUpstream
+	}
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		logger.Log("Failed to read from Google Book Search API response\n")
-		return []Book{}, errEmpty
+		return []Book{}, errUpstream
 	}
 
 	var getResult GoogleResponse
 	if err := json.Unmarshal(body, &getResult); err != nil {
 		logger.Log("Failed to unmashal Google Book Search API response\n")
-		return []Book{}, errEmpty
+		return []Book{}, errUpstream
 	}
 	var albums []Book
 
@@ -80,8 +165,27 @@ func (s *findBookService) Find(_ context.Context, query string) ([]Book, error)
 	return albums, nil
 }
 
-func (s *findBookService) ServiceStatus(_ context.Context) (int, error) {
+// ServiceStatus reports 503 while the breaker is open or the Google Book
+// Search API cannot be reached, and 200 otherwise.
+func (s *findBookService) ServiceStatus(ctx context.Context) (int, error) {
+	if s.breaker.State() == gobreaker.StateOpen {
+		return http.StatusServiceUnavailable, errUpstream
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, viper.GetString("apiEndpoint"), nil)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return http.StatusServiceUnavailable, errUpstream
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return http.StatusServiceUnavailable, errUpstream
+	}
 	return http.StatusOK, nil
 }
 
 var errEmpty = errors.New("Query is empty")
+
+var errUpstream = errors.New("Google Book Search API is unavailable")
@@ -0,0 +1,31 @@
+package book
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestFindRejectsUpstreamErrorStatus(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error": {"message": "quota exceeded"}}`))
+	}))
+	defer upstream.Close()
+	viper.Set("apiEndpoint", upstream.URL+"/?")
+	viper.Set("resultLimit", 5)
+	defer viper.Reset()
+
+	books, err := NewService().Find(context.Background(), "hobbit", 0, 5)
+	if !errors.Is(err, errUpstream) {
+		t.Errorf("err = %v, want %v", err, errUpstream)
+	}
+	if len(books) != 0 {
+		t.Errorf("got %d books, want none", len(books))
+	}
+}
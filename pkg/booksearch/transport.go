@@ -3,7 +3,9 @@ package book
 import (
 	"context"
 	"fmt"
+	"io"
 	book "microservices-with-go/api/book"
+	"microservices-with-go/pkg/requestid"
 	"os"
 	"time"
 
@@ -19,6 +21,7 @@ import (
 
 type grpcServer struct {
 	find          grpctransport.Handler
+	findStream    endpoint.Endpoint
 	serviceStatus grpctransport.Handler
 	book.UnimplementedBookServer
 }
@@ -29,11 +32,14 @@ func NewGRPCServer(endpoints Set) book.BookServer {
 			endpoints.SearchEndpoint,
 			decodeGRPCFindBookRequest,
 			encodeGRPCFindBookResponse,
+			grpctransport.ServerBefore(requestid.GRPCToContext()),
 		),
+		findStream: endpoints.SearchStreamEndpoint,
 		serviceStatus: grpctransport.NewServer(
 			endpoints.ServiceStatusEndpoint,
 			decodeGRPCServiceStatusRequest,
 			encodeGRPCServiceStatusResponse,
+			grpctransport.ServerBefore(requestid.GRPCToContext()),
 		),
 	}
 }
@@ -47,6 +53,29 @@ func (g *grpcServer) Find(ctx context.Context, r *book.FindBookRequest) (*book.F
 	return rep.(*book.FindBookResponse), nil
 }
 
+// FindStream sends the results of the search as the service finds them. An
+// error the service reports is sent as a last message carrying only Err, as
+// Find does.
+func (g *grpcServer) FindStream(r *book.FindBookRequest, stream book.Book_FindStreamServer) error {
+	logger.Log("Book transport", "FindStream")
+	request := bookSearchStreamRequest{
+		Query:  r.Query,
+		Offset: int(r.Offset),
+		Limit:  int(r.Limit),
+		Send: func(books []Book) error {
+			return stream.Send(&book.FindBookResponse{Books: localBookToPbBook(books)})
+		},
+	}
+	rep, err := g.findStream(requestid.IncomingContext(stream.Context()), request)
+	if err != nil {
+		return err
+	}
+	if reply := rep.(*bookSearchResponse); reply.Err != "" {
+		return stream.Send(&book.FindBookResponse{Err: reply.Err})
+	}
+	return nil
+}
+
 func (g *grpcServer) ServiceStatus(ctx context.Context, r *book.BookServiceStatusRequest) (*book.BookServiceStatusResponse, error) {
 	_, rep, err := g.serviceStatus.ServeGRPC(ctx, r)
 	if err != nil {
@@ -62,7 +91,7 @@ func (g *grpcServer) mustEmbedUnimplementedBookServer() {
 func decodeGRPCFindBookRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
 	req := grpcReq.(*book.FindBookRequest)
 	logger.Log("Decoding FindBookRequest for: ", req.Query)
-	return &bookSearchRequest{Query: req.Query}, nil
+	return &bookSearchRequest{Query: req.Query, Offset: int(req.Offset), Limit: int(req.Limit)}, nil
 }
 
 func decodeGRPCServiceStatusRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
@@ -73,7 +102,7 @@ func decodeGRPCServiceStatusRequest(_ context.Context, grpcReq interface{}) (int
 
 func encodeGRPCFindBookResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
 	reply := grpcReply.(bookSearchResponse)
-	logger.Log("Encoding FindBookResponse for: ", reply.Books[0].Title)
+	logger.Log("Encoding FindBookResponse, books: ", len(reply.Books))
 	return &book.FindBookResponse{Books: localBookToPbBook(reply.Books), Err: reply.Err}, nil
 }
 
@@ -103,6 +132,7 @@ func NewGRPCClient(conn *grpc.ClientConn) BookService {
 			encodeGRPCFindBookRequest,
 			decodeGRPCFindBookResponse,
 			book.FindBookResponse{},
+			grpctransport.ClientBefore(requestid.ContextToGRPC()),
 		).Endpoint()
 		findBookEndpoint = limiter(findBookEndpoint)
 		findBookEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
@@ -111,6 +141,16 @@ func NewGRPCClient(conn *grpc.ClientConn) BookService {
 		}))(findBookEndpoint)
 	}
 
+	var findBookStreamEndpoint endpoint.Endpoint
+	{
+		findBookStreamEndpoint = makeGRPCFindStreamEndpoint(book.NewBookClient(conn))
+		findBookStreamEndpoint = limiter(findBookStreamEndpoint)
+		findBookStreamEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "FindStream",
+			Timeout: 10 * time.Second,
+		}))(findBookStreamEndpoint)
+	}
+
 	var bookServiceStatusEndpoint endpoint.Endpoint
 	{
 		bookServiceStatusEndpoint = grpctransport.NewClient(
@@ -120,6 +160,7 @@ func NewGRPCClient(conn *grpc.ClientConn) BookService {
 			encodeGRPCServiceStatusRequest,
 			decodeGRPCServiceStatusResponse,
 			book.BookServiceStatusResponse{},
+			grpctransport.ClientBefore(requestid.ContextToGRPC()),
 		).Endpoint()
 		bookServiceStatusEndpoint = limiter(bookServiceStatusEndpoint)
 		bookServiceStatusEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
@@ -130,6 +171,7 @@ func NewGRPCClient(conn *grpc.ClientConn) BookService {
 
 	return Set{
 		SearchEndpoint:        findBookEndpoint,
+		SearchStreamEndpoint:  findBookStreamEndpoint,
 		ServiceStatusEndpoint: bookServiceStatusEndpoint,
 	}
 }
@@ -137,7 +179,35 @@ func NewGRPCClient(conn *grpc.ClientConn) BookService {
 func encodeGRPCFindBookRequest(_ context.Context, request interface{}) (interface{}, error) {
 	req := request.(bookSearchRequest)
 	logger.Log("Encoding FindBookRequest for: ", req.Query)
-	return &book.FindBookRequest{Query: req.Query}, nil
+	return &book.FindBookRequest{Query: req.Query, Offset: int32(req.Offset), Limit: int32(req.Limit)}, nil
+}
+
+// makeGRPCFindStreamEndpoint returns an endpoint calling FindStream, which
+// grpctransport.Client cannot do as it only supports unary calls. It passes
+// each message's books to the request's Send.
+func makeGRPCFindStreamEndpoint(client book.BookClient) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(bookSearchStreamRequest)
+		stream, err := client.FindStream(requestid.OutgoingContext(ctx), &book.FindBookRequest{Query: req.Query, Offset: int32(req.Offset), Limit: int32(req.Limit)})
+		if err != nil {
+			return nil, err
+		}
+		for {
+			reply, err := stream.Recv()
+			if err == io.EOF {
+				return &bookSearchResponse{Books: []Book{}}, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			if reply.Err != "" {
+				return &bookSearchResponse{Books: []Book{}, Err: reply.Err}, nil
+			}
+			if err := req.Send(pbBookToLocalBook(reply.Books)); err != nil {
+				return nil, err
+			}
+		}
+	}
 }
 
 func encodeGRPCServiceStatusRequest(_ context.Context, request interface{}) (interface{}, error) {
@@ -147,8 +217,8 @@ func encodeGRPCServiceStatusRequest(_ context.Context, request interface{}) (int
 
 func decodeGRPCFindBookResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
 	req := grpcRes.(*book.FindBookResponse)
-	logger.Log("Decoding FindBookResponse for: ", req.Books[0].Title)
-	return &bookSearchResponse{Books: pbBookToLocalBook(req.Books)}, nil
+	logger.Log("Decoding FindBookResponse, books: ", len(req.Books))
+	return &bookSearchResponse{Books: pbBookToLocalBook(req.Books), Err: req.Err}, nil
 }
 
 func pbBookToLocalBook(pbBooks []*book.Book) []Book {
@@ -163,7 +233,7 @@ func pbBookToLocalBook(pbBooks []*book.Book) []Book {
 func decodeGRPCServiceStatusResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
 	req := grpcRes.(*book.BookServiceStatusResponse)
 	logger.Log("Decoding ServiceStatusResponse for: ", req.Code)
-	return &serviceStatusResponse{Status: int(req.Code)}, nil
+	return &serviceStatusResponse{Status: int(req.Code), Err: req.Err}, nil
 }
 
 var logger log.Logger
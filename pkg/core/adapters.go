@@ -0,0 +1,294 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	albumtransport "microservices-with-go/pkg/albumsearch"
+	booktransport "microservices-with-go/pkg/booksearch"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/sd"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+)
+
+// findRequest is the request taken by the instance endpoints of the book and
+// album sources, which answer with the results as []mediaObject. An error the
+// service reports in its response is returned as the endpoint's response
+// rather than its error: the instance was reached, so it must neither count
+// as an instance failure nor be retried elsewhere.
+type findRequest struct {
+	query  string
+	offset int
+	limit  int
+}
+
+// findStreamRequest asks an instance endpoint to stream its results to send,
+// along with the position of each batch within the attempt's stream.
+type findStreamRequest struct {
+	findRequest
+	send func(position int, media []mediaObject) error
+}
+
+// statusRequest asks an instance endpoint for the instanceStatus its service
+// reports.
+type statusRequest struct{}
+
+// instanceStatus is the status code a service reports, with the reason it
+// gives when it is not healthy.
+type instanceStatus struct {
+	code   int
+	reason string
+}
+
+func init() {
+	RegisterSourceType("book", newBookSource)
+	RegisterSourceType("album", newAlbumSource)
+}
+
+// dialSource opens the connection an instance keeps for its whole lifetime.
+// The dial does not block: the connection is established in the background
+// and re-established with backoff whenever it breaks, so an instance that is
+// down at startup or restarts later is picked up without restarting the core.
+// Each connection attempt gives up after dialTimeout.
+func dialSource(address string, dialTimeout time.Duration) (*grpc.ClientConn, error) {
+	return grpc.Dial(
+		address,
+		grpc.WithInsecure(),
+		grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff:           backoff.DefaultConfig,
+			MinConnectTimeout: dialTimeout,
+		}),
+	)
+}
+
+// grpcSource is a source whose instances are reached through endpoints made
+// by an instance factory taking findRequest and findStreamRequest.
+type grpcSource struct {
+	pool *instancePool
+	find endpoint.Endpoint
+}
+
+func newGRPCSource(c SourceConfig, instancer sd.Instancer, factory sd.Factory) (MediaSource, error) {
+	pool := newInstancePool(c, instancer, factory)
+	find, err := pool.balancedEndpoint(c)
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return &grpcSource{pool: pool, find: find}, nil
+}
+
+func (s *grpcSource) Close() error {
+	return s.pool.Close()
+}
+
+func (s *grpcSource) Find(ctx context.Context, query string, offset int, limit int) ([]mediaObject, error) {
+	response, err := s.find(ctx, findRequest{query: query, offset: offset, limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	if err, ok := response.(error); ok {
+		return nil, reportedError{err}
+	}
+	return response.([]mediaObject), nil
+}
+
+// FindStream streams the results of an instance to send. A retry starts the
+// stream over on another instance, so the items a failed attempt already
+// delivered are skipped. If every attempt fails, the items delivered so far
+// are returned with the error. Nothing is sent once FindStream has returned,
+// even if the attempt it gave up on is still running.
+func (s *grpcSource) FindStream(ctx context.Context, query string, offset int, limit int, send func([]mediaObject) error) ([]mediaObject, error) {
+	var mtx sync.Mutex
+	var delivered []mediaObject
+	returned := false
+	defer func() {
+		mtx.Lock()
+		returned = true
+		mtx.Unlock()
+	}()
+
+	response, err := s.find(ctx, findStreamRequest{
+		findRequest: findRequest{query: query, offset: offset, limit: limit},
+		send: func(position int, media []mediaObject) error {
+			mtx.Lock()
+			defer mtx.Unlock()
+			if returned {
+				return context.Canceled
+			}
+			if skip := len(delivered) - position; skip > 0 {
+				if skip >= len(media) {
+					return nil
+				}
+				media = media[skip:]
+			}
+			delivered = append(delivered, media...)
+			return send(media)
+		},
+	})
+	if err == nil {
+		if reported, ok := response.(error); ok {
+			err = reportedError{reported}
+		}
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+	return append([]mediaObject(nil), delivered...), err
+}
+
+// ServiceStatus checks every instance, ejected or not, in parallel. The checks
+// bypass the balancer so that they neither count towards ejections nor reset
+// them.
+func (s *grpcSource) ServiceStatus(ctx context.Context) []dependencyStatus {
+	instances := s.pool.all()
+	statuses := make([]dependencyStatus, len(instances))
+	var wg sync.WaitGroup
+	for i, instance := range instances {
+		wg.Add(1)
+		go func(i int, instance *trackedInstance) {
+			defer wg.Done()
+			begin := time.Now()
+			response, err := instance.direct(ctx, statusRequest{})
+			status := dependencyStatus{
+				Address:   instance.address,
+				Status:    sourceOK,
+				LatencyMs: float64(time.Since(begin).Microseconds()) / 1000,
+			}
+			if err != nil {
+				status.Status, status.Err = classify(ctx, err)
+			} else if reported := response.(instanceStatus); reported.code != http.StatusOK {
+				status.Status, status.Err = sourceError, fmt.Sprintf("service reported status %d", reported.code)
+				if reported.reason != "" {
+					status.Err += ": " + reported.reason
+				}
+			}
+			statuses[i] = status
+		}(i, instance)
+	}
+	wg.Wait()
+	return statuses
+}
+
+// streamTo returns the function an instance endpoint passes each batch of a
+// stream to, tracking the batch's position in the stream.
+func streamTo(req findStreamRequest) func([]mediaObject) error {
+	position := 0
+	return func(media []mediaObject) error {
+		err := req.send(position, media)
+		position += len(media)
+		return err
+	}
+}
+
+func newBookSource(c SourceConfig, instancer sd.Instancer) (MediaSource, error) {
+	return newGRPCSource(c, instancer, bookInstance(c))
+}
+
+func bookInstance(c SourceConfig) sd.Factory {
+	return func(address string) (endpoint.Endpoint, io.Closer, error) {
+		conn, err := dialSource(address, c.DialTimeout)
+		if err != nil {
+			return nil, nil, err
+		}
+		client := booktransport.NewGRPCClient(conn)
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			var media []mediaObject
+			var err error
+			switch req := request.(type) {
+			case findStreamRequest:
+				send := streamTo(req)
+				err = client.FindStream(ctx, req.query, req.offset, req.limit, func(books []booktransport.Book) error {
+					return send(booksToMedia(books))
+				})
+			case findRequest:
+				var books []booktransport.Book
+				books, err = client.Find(ctx, req.query, req.offset, req.limit)
+				media = booksToMedia(books)
+			case statusRequest:
+				code, err := client.ServiceStatus(ctx)
+				var serviceErr booktransport.ServiceError
+				if errors.As(err, &serviceErr) {
+					return instanceStatus{code, string(serviceErr)}, nil
+				}
+				return instanceStatus{code: code}, err
+			}
+			var serviceErr booktransport.ServiceError
+			if errors.As(err, &serviceErr) {
+				return serviceErr, nil
+			}
+			return media, err
+		}, conn, nil
+	}
+}
+
+func booksToMedia(books []booktransport.Book) []mediaObject {
+	var media []mediaObject
+	for _, b := range books {
+		media = append(media, mediaObject{
+			Title:      b.Title,
+			Artist:     b.Author,
+			EntityType: "book",
+		})
+	}
+	return media
+}
+
+func newAlbumSource(c SourceConfig, instancer sd.Instancer) (MediaSource, error) {
+	return newGRPCSource(c, instancer, albumInstance(c))
+}
+
+func albumInstance(c SourceConfig) sd.Factory {
+	return func(address string) (endpoint.Endpoint, io.Closer, error) {
+		conn, err := dialSource(address, c.DialTimeout)
+		if err != nil {
+			return nil, nil, err
+		}
+		client := albumtransport.NewGRPCClient(conn)
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			var media []mediaObject
+			var err error
+			switch req := request.(type) {
+			case findStreamRequest:
+				send := streamTo(req)
+				err = client.FindStream(ctx, req.query, req.offset, req.limit, func(albums []albumtransport.Album) error {
+					return send(albumsToMedia(albums))
+				})
+			case findRequest:
+				var albums []albumtransport.Album
+				albums, err = client.Find(ctx, req.query, req.offset, req.limit)
+				media = albumsToMedia(albums)
+			case statusRequest:
+				code, err := client.ServiceStatus(ctx)
+				var serviceErr albumtransport.ServiceError
+				if errors.As(err, &serviceErr) {
+					return instanceStatus{code, string(serviceErr)}, nil
+				}
+				return instanceStatus{code: code}, err
+			}
+			var serviceErr albumtransport.ServiceError
+			if errors.As(err, &serviceErr) {
+				return serviceErr, nil
+			}
+			return media, err
+		}, conn, nil
+	}
+}
+
+func albumsToMedia(albums []albumtransport.Album) []mediaObject {
+	var media []mediaObject
+	for _, a := range albums {
+		media = append(media, mediaObject{
+			Title:      a.Title,
+			Artist:     a.Artist,
+			EntityType: "album",
+		})
+	}
+	return media
+}
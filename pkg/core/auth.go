@@ -0,0 +1,196 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/metrics"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+const (
+	apiKeyHeader   = "X-API-Key"
+	apiKeyMetadata = "x-api-key"
+)
+
+var apiKeyChallenge = fmt.Sprintf("ApiKey header=%q", apiKeyHeader)
+
+type apiKeyContextKey struct{}
+
+// apiKeyNameContextKey holds the name of the key a request was let through
+// with.
+type apiKeyNameContextKey struct{}
+
+// APIKey describes one entry of the "keys" list of the key file. A quota of
+// 0 is unlimited.
+type APIKey struct {
+	Name      string `mapstructure:"name"`
+	Key       string `mapstructure:"key"`
+	PerMinute int    `mapstructure:"perMinute"`
+	PerDay    int    `mapstructure:"perDay"`
+}
+
+func (k APIKey) validate() error {
+	switch {
+	case k.Name == "":
+		return errors.New("name is required")
+	case k.Key == "":
+		return errors.New("key is required")
+	case k.PerMinute < 0:
+		return errors.New("perMinute must not be negative")
+	case k.PerDay < 0:
+		return errors.New("perDay must not be negative")
+	}
+	return nil
+}
+
+// quota holds the token buckets of one key. A nil bucket is unlimited.
+type quota struct {
+	name    string
+	buckets []*rate.Limiter
+}
+
+// take spends a token from every bucket, or none of them and returns how
+// long until all of them have one.
+func (q *quota) take(now time.Time) (time.Duration, bool) {
+	var wait time.Duration
+	reservations := make([]*rate.Reservation, 0, len(q.buckets))
+	for _, b := range q.buckets {
+		r := b.ReserveN(now, 1)
+		reservations = append(reservations, r)
+		if d := r.DelayFrom(now); d > wait {
+			wait = d
+		}
+	}
+	if wait == 0 {
+		return 0, true
+	}
+	for _, r := range reservations {
+		r.CancelAt(now)
+	}
+	return wait, false
+}
+
+// NewAPIKeyMiddleware lets through requests carrying one of keys, as put in
+// the context by the transports, for as long as the key's quotas last.
+// Requests are counted by key name and result.
+func NewAPIKeyMiddleware(keys []APIKey, requests metrics.Counter) (endpoint.Middleware, error) {
+	quotas := make(map[[sha256.Size]byte]*quota, len(keys))
+	names := make(map[string]bool, len(keys))
+	for i, k := range keys {
+		if err := k.validate(); err != nil {
+			return nil, fmt.Errorf("key %d: %w", i, err)
+		}
+		digest := sha256.Sum256([]byte(k.Key))
+		if _, ok := quotas[digest]; ok {
+			return nil, fmt.Errorf("key %q: duplicate key", k.Name)
+		}
+		if names[k.Name] {
+			return nil, fmt.Errorf("key %q: duplicate name", k.Name)
+		}
+		names[k.Name] = true
+		q := &quota{name: k.Name}
+		if k.PerMinute > 0 {
+			q.buckets = append(q.buckets, rate.NewLimiter(rate.Every(time.Minute/time.Duration(k.PerMinute)), k.PerMinute))
+		}
+		if k.PerDay > 0 {
+			q.buckets = append(q.buckets, rate.NewLimiter(rate.Every(24*time.Hour/time.Duration(k.PerDay)), k.PerDay))
+		}
+		quotas[digest] = q
+	}
+
+	var mtx sync.Mutex
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			key, _ := ctx.Value(apiKeyContextKey{}).(string)
+			if key == "" {
+				requests.With("key", "", "result", "missing").Add(1)
+				return nil, unauthorizedError{errors.New("missing API key"), apiKeyChallenge}
+			}
+			// Keys are looked up by digest so that the lookup takes the
+			// same time whatever the key shares with a valid one.
+			q, ok := quotas[sha256.Sum256([]byte(key))]
+			if !ok {
+				requests.With("key", "", "result", "invalid").Add(1)
+				return nil, unauthorizedError{errors.New("invalid API key"), apiKeyChallenge}
+			}
+			mtx.Lock()
+			wait, ok := q.take(time.Now())
+			mtx.Unlock()
+			if !ok {
+				requests.With("key", q.name, "result", "quota_exceeded").Add(1)
+				return nil, quotaExceededError{retryAfter: wait}
+			}
+			requests.With("key", q.name, "result", "allowed").Add(1)
+			return next(context.WithValue(ctx, apiKeyNameContextKey{}, q.name), request)
+		}
+	}, nil
+}
+
+// apiKeyToHTTPContext moves the API key header into the context.
+func apiKeyToHTTPContext(ctx context.Context, r *http.Request) context.Context {
+	return context.WithValue(ctx, apiKeyContextKey{}, r.Header.Get(apiKeyHeader))
+}
+
+// apiKeyToGRPCContext moves the API key metadata into the context.
+func apiKeyToGRPCContext(ctx context.Context, md metadata.MD) context.Context {
+	if v := md.Get(apiKeyMetadata); len(v) > 0 {
+		return context.WithValue(ctx, apiKeyContextKey{}, v[0])
+	}
+	return ctx
+}
+
+// unauthorizedError is answered with 401 Unauthorized, or Unauthenticated
+// over gRPC.
+type unauthorizedError struct {
+	err       error
+	challenge string
+}
+
+func (e unauthorizedError) Error() string { return e.err.Error() }
+
+func (unauthorizedError) StatusCode() int { return http.StatusUnauthorized }
+
+func (e unauthorizedError) Headers() http.Header {
+	return http.Header{"Www-Authenticate": []string{e.challenge}}
+}
+
+func (e unauthorizedError) GRPCStatus() *grpcstatus.Status {
+	return grpcstatus.New(codes.Unauthenticated, e.Error())
+}
+
+// quotaExceededError is answered with 429 Too Many Requests and when to try
+// again, or ResourceExhausted over gRPC.
+type quotaExceededError struct {
+	retryAfter time.Duration
+}
+
+func (e quotaExceededError) Error() string {
+	return fmt.Sprintf("quota exceeded, retry in %s", e.retryAfter.Round(time.Second))
+}
+
+func (quotaExceededError) StatusCode() int { return http.StatusTooManyRequests }
+
+func (e quotaExceededError) Headers() http.Header {
+	return retryAfterHeader(e.retryAfter)
+}
+
+func (e quotaExceededError) GRPCStatus() *grpcstatus.Status {
+	return grpcstatus.New(codes.ResourceExhausted, e.Error())
+}
+
+func retryAfterHeader(d time.Duration) http.Header {
+	seconds := int(math.Ceil(d.Seconds()))
+	return http.Header{"Retry-After": []string{strconv.Itoa(seconds)}}
+}
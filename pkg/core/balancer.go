@@ -0,0 +1,254 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"microservices-with-go/pkg/registry"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/sd"
+	"github.com/go-kit/kit/sd/lb"
+	"github.com/spf13/viper"
+)
+
+const (
+	roundRobinBalancer  = "round-robin"
+	leastLoadedBalancer = "least-loaded"
+
+	defaultEjectAfter = 3
+	defaultCoolOff    = 30 * time.Second
+)
+
+// trackedInstance is one backend instance of a source, together with the
+// bookkeeping the balancers need.
+type trackedInstance struct {
+	address  string
+	endpoint endpoint.Endpoint
+	direct   endpoint.Endpoint
+	closer   io.Closer
+	inflight int64
+
+	mtx          sync.Mutex
+	failures     int
+	ejectedUntil time.Time
+}
+
+func (i *trackedInstance) ejected(now time.Time) bool {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+	return now.Before(i.ejectedUntil)
+}
+
+// instancePool keeps one endpoint per instance announced by an sd.Instancer,
+// and stops the instancer when closed. An instance that fails ejectAfter times
+// in a row is left out of Endpoints for coolOff; the first failure after it is
+// let back in ejects it again.
+type instancePool struct {
+	name       string
+	ejectAfter int
+	coolOff    time.Duration
+	instancer  sd.Instancer
+	endpointer *sd.DefaultEndpointer
+
+	mtx       sync.RWMutex
+	instances []*trackedInstance
+}
+
+func newInstancePool(c SourceConfig, instancer sd.Instancer, factory sd.Factory) *instancePool {
+	p := &instancePool{
+		name:       c.Name,
+		ejectAfter: c.EjectAfter,
+		coolOff:    c.CoolOff,
+		instancer:  instancer,
+	}
+	p.endpointer = sd.NewEndpointer(instancer, p.track(factory), logger)
+	return p
+}
+
+// track wraps factory so that every endpoint it creates is tracked by the
+// pool for as long as the endpoint cache keeps it.
+func (p *instancePool) track(factory sd.Factory) sd.Factory {
+	return func(address string) (endpoint.Endpoint, io.Closer, error) {
+		e, closer, err := factory(address)
+		if err != nil {
+			return nil, nil, err
+		}
+		instance := &trackedInstance{address: address, direct: e, closer: closer}
+		instance.endpoint = p.observe(instance, e)
+		p.add(instance)
+		return instance.endpoint, closerFunc(func() error { return p.remove(address) }), nil
+	}
+}
+
+func (p *instancePool) observe(instance *trackedInstance, next endpoint.Endpoint) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		atomic.AddInt64(&instance.inflight, 1)
+		defer atomic.AddInt64(&instance.inflight, -1)
+
+		response, err := next(ctx, request)
+
+		instance.mtx.Lock()
+		defer instance.mtx.Unlock()
+		switch {
+		case err == nil:
+			instance.failures = 0
+		case errors.Is(ctx.Err(), context.Canceled):
+			// The caller gave up, which says nothing about the instance.
+		default:
+			instance.failures++
+			now := time.Now()
+			if instance.failures >= p.ejectAfter && !now.Before(instance.ejectedUntil) {
+				instance.ejectedUntil = now.Add(p.coolOff)
+				logger.Log("source", p.name, "instance", instance.address, "ejected_for", p.coolOff, "err", err)
+			}
+		}
+		return response, err
+	}
+}
+
+func (p *instancePool) add(instance *trackedInstance) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.instances = append(p.instances, instance)
+	sort.Slice(p.instances, func(i, j int) bool { return p.instances[i].address < p.instances[j].address })
+}
+
+func (p *instancePool) remove(address string) error {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	for i, instance := range p.instances {
+		if instance.address == address {
+			p.instances = append(p.instances[:i], p.instances[i+1:]...)
+			return instance.closer.Close()
+		}
+	}
+	return nil
+}
+
+// all returns every instance, ejected or not.
+func (p *instancePool) all() []*trackedInstance {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+	return append([]*trackedInstance(nil), p.instances...)
+}
+
+// available returns the instances that are not ejected. If every instance is
+// ejected all of them are returned, since failing over to nothing cannot
+// do better than trying them anyway.
+func (p *instancePool) available() []*trackedInstance {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+	now := time.Now()
+	var available []*trackedInstance
+	for _, instance := range p.instances {
+		if !instance.ejected(now) {
+			available = append(available, instance)
+		}
+	}
+	if len(available) == 0 {
+		return append(available, p.instances...)
+	}
+	return available
+}
+
+// Endpoints implements sd.Endpointer.
+func (p *instancePool) Endpoints() ([]endpoint.Endpoint, error) {
+	var endpoints []endpoint.Endpoint
+	for _, instance := range p.available() {
+		endpoints = append(endpoints, instance.endpoint)
+	}
+	return endpoints, nil
+}
+
+func (p *instancePool) Close() error {
+	p.endpointer.Close()
+	p.instancer.Stop()
+	p.mtx.Lock()
+	instances := p.instances
+	p.instances = nil
+	p.mtx.Unlock()
+
+	var firstErr error
+	for _, instance := range instances {
+		if err := instance.closer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// balancedEndpoint returns an endpoint that spreads requests over the pool
+// with the configured balancer, retrying failed requests on another pick.
+func (p *instancePool) balancedEndpoint(c SourceConfig) (endpoint.Endpoint, error) {
+	var balancer lb.Balancer
+	switch c.Balancer {
+	case "", roundRobinBalancer:
+		balancer = lb.NewRoundRobin(p)
+	case leastLoadedBalancer:
+		balancer = &leastLoaded{pool: p}
+	default:
+		return nil, fmt.Errorf("unknown balancer %q, supported balancers are %v", c.Balancer, []string{roundRobinBalancer, leastLoadedBalancer})
+	}
+	return lb.Retry(c.Retries+1, c.Timeout, balancer), nil
+}
+
+// leastLoaded picks the available instance with the fewest requests in flight,
+// rotating between instances that are tied.
+type leastLoaded struct {
+	pool *instancePool
+	next uint64
+}
+
+func (b *leastLoaded) Endpoint() (endpoint.Endpoint, error) {
+	instances := b.pool.available()
+	if len(instances) == 0 {
+		return nil, lb.ErrNoEndpoints
+	}
+	offset := int(atomic.AddUint64(&b.next, 1) % uint64(len(instances)))
+	var best *trackedInstance
+	var bestLoad int64
+	for i := range instances {
+		instance := instances[(offset+i)%len(instances)]
+		load := atomic.LoadInt64(&instance.inflight)
+		if best == nil || load < bestLoad {
+			best, bestLoad = instance, load
+		}
+	}
+	return best.endpoint, nil
+}
+
+// sourceInstancer returns the instancer announcing a source's instances: the
+// addresses listed in its config or in its static discovery file, and the
+// instances of its type registered with instances, if any.
+func sourceInstancer(c SourceConfig, instances *registry.Registry) (sd.Instancer, error) {
+	addresses := c.Addresses
+	if c.Address != "" {
+		addresses = append([]string{c.Address}, addresses...)
+	}
+	if c.DiscoveryFile != "" {
+		discovery := viper.New()
+		discovery.SetConfigFile(c.DiscoveryFile)
+		if err := discovery.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("reading discovery file: %w", err)
+		}
+		addresses = append(addresses, discovery.GetStringSlice(c.Name)...)
+	}
+	if instances != nil {
+		return instances.Instancer(c.Type, addresses...), nil
+	}
+	if len(addresses) == 0 {
+		return nil, errors.New("no instance addresses configured")
+	}
+	return sd.FixedInstancer(addresses), nil
+}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
@@ -0,0 +1,180 @@
+package core
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	"golang.org/x/sync/singleflight"
+)
+
+// CachingMiddleware answers repeated searches from an in-process LRU cache.
+// Identical searches running at the same time are coalesced into one call to
+// the next service. Results in which a source failed are not cached. Results
+// answered from the cache are marked Cached, with the latencies of their
+// sources cleared since no source was queried.
+type CachingMiddleware struct {
+	Next QueryService
+
+	defaults  ServiceConfig
+	ttl       time.Duration
+	size      int
+	hits      metrics.Counter
+	misses    metrics.Counter
+	evictions metrics.Counter
+
+	mtx     sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	flights singleflight.Group
+}
+
+type cacheEntry struct {
+	key     string
+	result  searchResult
+	expires time.Time
+}
+
+// NewCachingMiddleware returns a cache keeping up to size results for ttl.
+// Searches are told apart once the defaults of the next service's config are
+// applied, so that leaving an option out and passing its default share an
+// entry. evictions is labelled with the reason an entry was dropped.
+func NewCachingMiddleware(size int, ttl time.Duration, defaults ServiceConfig, hits, misses, evictions metrics.Counter, next QueryService) *CachingMiddleware {
+	return &CachingMiddleware{
+		Next:      next,
+		defaults:  defaults,
+		ttl:       ttl,
+		size:      size,
+		hits:      hits,
+		misses:    misses,
+		evictions: evictions,
+		entries:   map[string]*list.Element{},
+		lru:       list.New(),
+	}
+}
+
+func (mw *CachingMiddleware) Search(c context.Context, q searchQuery) (searchResult, error) {
+	key := cacheKey(mw.defaults.withDefaults(q))
+	if result, ok := mw.get(key); ok {
+		mw.hits.Add(1)
+		return cachedResult(result), nil
+	}
+	mw.misses.Add(1)
+
+	flight := mw.flights.DoChan(key, func() (interface{}, error) {
+		result, err := mw.Next.Search(detached{c}, q)
+		if err == nil && !result.degraded() {
+			mw.put(key, result)
+		}
+		return result, err
+	})
+	select {
+	case res := <-flight:
+		return res.Val.(searchResult), res.Err
+	case <-c.Done():
+		return searchResult{Data: []mediaObject{}}, c.Err()
+	}
+}
+
+// StreamSearch is not cached: its point is to pass results on as the sources
+// answer.
+func (mw *CachingMiddleware) StreamSearch(c context.Context, q searchQuery, emit func(sourceEvent) error) (searchResult, error) {
+	return mw.Next.StreamSearch(c, q, emit)
+}
+
+func (mw *CachingMiddleware) ServiceStatus(c context.Context) (healthReport, error) {
+	return mw.Next.ServiceStatus(c)
+}
+
+// Warm searches for each of queries with the default options, so that their
+// results are cached before traffic arrives. Failed searches are logged and
+// skipped.
+func (mw *CachingMiddleware) Warm(c context.Context, queries []string) {
+	for _, query := range queries {
+		result, err := mw.Search(c, searchQuery{Text: query})
+		if err == nil && result.degraded() {
+			err = errors.New("some sources failed")
+		}
+		if err != nil {
+			logger.Log("during", "Warm", "query", query, "err", err)
+		}
+	}
+}
+
+func (mw *CachingMiddleware) get(key string) (searchResult, bool) {
+	mw.mtx.Lock()
+	defer mw.mtx.Unlock()
+	element, ok := mw.entries[key]
+	if !ok {
+		return searchResult{}, false
+	}
+	entry := element.Value.(*cacheEntry)
+	if time.Now().After(entry.expires) {
+		mw.remove(element, "expired")
+		return searchResult{}, false
+	}
+	mw.lru.MoveToFront(element)
+	return entry.result, true
+}
+
+func (mw *CachingMiddleware) put(key string, result searchResult) {
+	mw.mtx.Lock()
+	defer mw.mtx.Unlock()
+	if element, ok := mw.entries[key]; ok {
+		element.Value.(*cacheEntry).result = result
+		element.Value.(*cacheEntry).expires = time.Now().Add(mw.ttl)
+		mw.lru.MoveToFront(element)
+		return
+	}
+	mw.entries[key] = mw.lru.PushFront(&cacheEntry{key: key, result: result, expires: time.Now().Add(mw.ttl)})
+	for mw.lru.Len() > mw.size {
+		mw.remove(mw.lru.Back(), "size")
+	}
+}
+
+func (mw *CachingMiddleware) remove(element *list.Element, reason string) {
+	mw.lru.Remove(element)
+	delete(mw.entries, element.Value.(*cacheEntry).key)
+	mw.evictions.With("reason", reason).Add(1)
+}
+
+func cachedResult(result searchResult) searchResult {
+	result.Cached = true
+	sources := make([]sourceStatus, len(result.Sources))
+	for i, status := range result.Sources {
+		status.LatencyMs = 0
+		sources[i] = status
+	}
+	result.Sources = sources
+	return result
+}
+
+// cacheKey identifies a search by its normalized text and options.
+func cacheKey(q searchQuery) string {
+	q.Text = strings.Join(strings.Fields(strings.ToLower(q.Text)), " ")
+	q.Types = sortedCopy(q.Types)
+	q.Sources = sortedCopy(q.Sources)
+	key, _ := json.Marshal(q)
+	return string(key)
+}
+
+func sortedCopy(values []string) []string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// detached keeps the values of a context but not its cancellation, so that a
+// coalesced search is not cancelled when the caller that started it goes away
+// while others still wait for it.
+type detached struct{ context.Context }
+
+func (detached) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detached) Done() <-chan struct{}       { return nil }
+func (detached) Err() error                  { return nil }
@@ -0,0 +1,77 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/metrics/discard"
+)
+
+// countingService answers every search with no results and counts them.
+type countingService struct {
+	QueryService
+	searches int
+}
+
+func (s *countingService) Search(context.Context, searchQuery) (searchResult, error) {
+	s.searches++
+	return searchResult{Data: []mediaObject{}}, nil
+}
+
+func TestCachingMiddlewareAppliesDefaults(t *testing.T) {
+	defaults := ServiceConfig{Ranking: scoreRanking, DefaultLimit: 5, MaxLimit: 50}
+	next := &countingService{}
+	cache := NewCachingMiddleware(10, time.Minute, defaults, discard.NewCounter(), discard.NewCounter(), discard.NewCounter(), next)
+
+	same := []searchQuery{
+		{Text: "hobbit"},
+		{Text: "hobbit", Limit: 5},
+		{Text: "hobbit", Ranking: scoreRanking},
+		{Text: " Hobbit ", Ranking: scoreRanking, Limit: 5},
+	}
+	for _, q := range same {
+		if _, err := cache.Search(context.Background(), q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if next.searches != 1 {
+		t.Errorf("%d searches reached the service, want 1", next.searches)
+	}
+
+	if _, err := cache.Search(context.Background(), searchQuery{Text: "hobbit", Limit: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if next.searches != 2 {
+		t.Errorf("a search with another limit was answered from the cache")
+	}
+}
+
+// timedService answers every search with one source that took a while.
+type timedService struct {
+	QueryService
+}
+
+func (timedService) Search(context.Context, searchQuery) (searchResult, error) {
+	return searchResult{Data: []mediaObject{}, Sources: []sourceStatus{{Name: "book", Status: sourceOK, LatencyMs: 120}}}, nil
+}
+
+func TestCachingMiddlewareMarksHits(t *testing.T) {
+	cache := NewCachingMiddleware(10, time.Minute, ServiceConfig{}, discard.NewCounter(), discard.NewCounter(), discard.NewCounter(), timedService{})
+
+	miss, err := cache.Search(context.Background(), searchQuery{Text: "hobbit"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if miss.Cached || miss.Sources[0].LatencyMs != 120 {
+		t.Errorf("first search = %+v, want it answered by the service", miss)
+	}
+
+	hit, err := cache.Search(context.Background(), searchQuery{Text: "hobbit"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hit.Cached || hit.Sources[0].LatencyMs != 0 {
+		t.Errorf("second search = %+v, want it marked cached without latencies", hit)
+	}
+}
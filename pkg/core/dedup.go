@@ -0,0 +1,62 @@
+package core
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// editionPhrase matches the words an edition marker ends with, such as "75th
+// Anniversary Edition", "2nd ed." or "Remastered 2011", after which a year
+// may follow.
+const editionPhrase = `([^-:,/\(\)\[\]]*\s)?(edition|ed\.|remaster|remastered|deluxe|expanded|anniversary|reissue|unabridged|abridged)(\s+\d{4})?`
+
+var (
+	bracketedEdition = regexp.MustCompile(`\s*[\(\[]` + editionPhrase + `[\)\]]`)
+	trailingEdition  = regexp.MustCompile(`\s*[-:,/]\s*` + editionPhrase + `$`)
+)
+
+// dedup collapses items whose normalized title and creator match into the
+// first of them, which keeps the others as alternates. An item without a
+// creator matches any creator of the same type. The order of the surviving
+// items is kept.
+func dedup(media []mediaObject) []mediaObject {
+	type key struct{ title, creator string }
+	var survivors []mediaObject
+	var keys []key
+	for _, m := range media {
+		k := key{normalize(m.Title), normalize(m.Artist)}
+		merged := false
+		for i, other := range keys {
+			sameType := m.EntityType == survivors[i].EntityType
+			if k.title == other.title && (k.creator == other.creator || sameType && (k.creator == "" || other.creator == "")) {
+				survivors[i].Alternates = append(survivors[i].Alternates, m)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			survivors = append(survivors, m)
+			keys = append(keys, k)
+		}
+	}
+	return survivors
+}
+
+// normalize lowercases s, strips diacritics, edition markers and punctuation
+// and collapses whitespace, so that "The Hobbit (75th Anniversary Edition)"
+// and "the hobbit" compare equal. Only markers ending with an edition word
+// are stripped, so that "Love, Version 2.0" keeps its subtitle.
+func normalize(s string) string {
+	s = strings.ToLower(s)
+	if stripped, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), s); err == nil {
+		s = stripped
+	}
+	s = bracketedEdition.ReplaceAllString(s, "")
+	s = trailingEdition.ReplaceAllString(s, "")
+	return strings.Join(terms(s), " ")
+}
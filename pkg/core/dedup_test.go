@@ -0,0 +1,83 @@
+package core
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"The Hobbit", "the hobbit"},
+		{"The Hobbit (75th Anniversary Edition)", "the hobbit"},
+		{"The Hobbit [Unabridged]", "the hobbit"},
+		{"The Hobbit, 2nd ed.", "the hobbit"},
+		{"Abbey Road - Remastered 2009", "abbey road"},
+		{"Abbey Road (2019 Remaster)", "abbey road"},
+		{"Rumours: Deluxe Edition", "rumours"},
+		{"Señor Café", "senor cafe"},
+		{"Love, Version 2.0", "love version 2 0"},
+		{"Live Version (Acoustic)", "live version acoustic"},
+		{"The Edition Wars, Part One", "the edition wars part one"},
+	}
+	for _, tt := range tests {
+		if got := normalize(tt.title); got != tt.want {
+			t.Errorf("normalize(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestDedup(t *testing.T) {
+	tests := []struct {
+		name  string
+		media []mediaObject
+		want  int
+	}{
+		{
+			name: "editions of the same book",
+			media: []mediaObject{
+				{Title: "The Hobbit", Artist: "J.R.R. Tolkien", EntityType: "book"},
+				{Title: "The Hobbit (Deluxe Edition)", Artist: "J.R.R. Tolkien", EntityType: "book"},
+			},
+			want: 1,
+		},
+		{
+			name: "subtitle that is not an edition",
+			media: []mediaObject{
+				{Title: "Love", Artist: "Someone", EntityType: "album"},
+				{Title: "Love, Version 2.0", Artist: "Someone", EntityType: "album"},
+			},
+			want: 2,
+		},
+		{
+			name: "missing creator within a type",
+			media: []mediaObject{
+				{Title: "The Hobbit", Artist: "J.R.R. Tolkien", EntityType: "book"},
+				{Title: "The Hobbit", EntityType: "book"},
+			},
+			want: 1,
+		},
+		{
+			name: "missing creator across types",
+			media: []mediaObject{
+				{Title: "The Hobbit", EntityType: "book"},
+				{Title: "The Hobbit", Artist: "Howard Shore", EntityType: "album"},
+			},
+			want: 2,
+		},
+		{
+			name: "different creators",
+			media: []mediaObject{
+				{Title: "Greatest Hits", Artist: "Queen", EntityType: "album"},
+				{Title: "Greatest Hits", Artist: "ABBA", EntityType: "album"},
+			},
+			want: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dedup(tt.media); len(got) != tt.want {
+				t.Errorf("dedup kept %d items, want %d: %+v", len(got), tt.want, got)
+			}
+		})
+	}
+}
@@ -2,55 +2,179 @@ package core
 
 import (
 	"context"
+	"errors"
+	"net/http"
+
+	"microservices-with-go/pkg/requestid"
 
 	"github.com/go-kit/kit/endpoint"
 )
 
 type userSearchRequest struct {
-	Query string `json:"query"`
+	Query   string   `json:"query"`
+	Ranking string   `json:"ranking,omitempty"`
+	Limit   int      `json:"limit,omitempty"`
+	Cursor  string   `json:"cursor,omitempty"`
+	Types   []string `json:"types,omitempty"`
+	Sources []string `json:"sources,omitempty"`
 }
 
 type userSearchResponse struct {
-	Data []mediaObject `json:"data"`
-	Err  string        `json:"err,omitempty"`
+	Data       []mediaObject  `json:"data"`
+	TimedOut   []string       `json:"timed_out,omitempty"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	Sources    []sourceStatus `json:"sources,omitempty"`
+	Cached     bool           `json:"cached,omitempty"`
+	Err        string         `json:"err,omitempty"`
+	RequestID  string         `json:"request_id,omitempty"`
+}
+
+func (r userSearchRequest) searchQuery() searchQuery {
+	return searchQuery{Text: r.Query, Ranking: r.Ranking, Limit: r.Limit, Cursor: r.Cursor, Types: r.Types, Sources: r.Sources}
+}
+
+func newUserSearchRequest(q searchQuery) userSearchRequest {
+	return userSearchRequest{Query: q.Text, Ranking: q.Ranking, Limit: q.Limit, Cursor: q.Cursor, Types: q.Types, Sources: q.Sources}
+}
+
+func newUserSearchResponse(r searchResult) userSearchResponse {
+	return userSearchResponse{Data: r.Data, TimedOut: r.TimedOut, NextCursor: r.NextCursor, Sources: r.Sources, Cached: r.Cached}
+}
+
+func (r userSearchResponse) searchResult() searchResult {
+	return searchResult{Data: r.Data, TimedOut: r.TimedOut, NextCursor: r.NextCursor, Sources: r.Sources, Cached: r.Cached}
+}
+
+// userSearchStreamRequest is a search whose per-source results are passed to
+// Emit as they arrive.
+type userSearchStreamRequest struct {
+	userSearchRequest
+	Emit func(sourceEvent) error
 }
 
 type serviceStatusRequest struct{}
 
 type serviceStatusResponse struct {
-	Status int    `json:"status"`
-	Err    string `json:"err,omitempty"`
+	Status       string             `json:"status"`
+	Code         int                `json:"code"`
+	Dependencies []dependencyStatus `json:"dependencies"`
+	Err          string             `json:"err,omitempty"`
+	RequestID    string             `json:"request_id,omitempty"`
 }
 
+// StatusCode serves the response with the code of the core's state.
+func (r serviceStatusResponse) StatusCode() int { return r.Code }
+
 type Set struct {
 	SearchEndpoint        endpoint.Endpoint
+	SearchStreamEndpoint  endpoint.Endpoint
 	ServiceStatusEndpoint endpoint.Endpoint
 }
 
 func NewEndpointSet(service QueryService) Set {
 	return Set{
 		SearchEndpoint:        makeUserSearchEndpoint(service),
+		SearchStreamEndpoint:  makeUserSearchStreamEndpoint(service),
 		ServiceStatusEndpoint: makeServiceStatusEndpoint(service),
 	}
 }
 
+func (s Set) Search(ctx context.Context, q searchQuery) (searchResult, error) {
+	resp, err := s.SearchEndpoint(ctx, newUserSearchRequest(q))
+	if err != nil {
+		return searchResult{Data: []mediaObject{}}, err
+	}
+	response := resp.(userSearchResponse)
+	if response.Err != "" {
+		return response.searchResult(), errors.New(response.Err)
+	}
+	return response.searchResult(), nil
+}
+
+// StreamSearch runs the search through SearchStreamEndpoint. A set without
+// one, such as a gRPC client's, runs a plain search and emits each source's
+// results once it completes.
+func (s Set) StreamSearch(ctx context.Context, q searchQuery, emit func(sourceEvent) error) (searchResult, error) {
+	if s.SearchStreamEndpoint == nil {
+		result, err := s.Search(ctx, q)
+		for _, status := range result.Sources {
+			event := sourceEvent{Source: status, Data: []mediaObject{}}
+			for _, m := range result.Data {
+				if m.Source == status.Name {
+					event.Data = append(event.Data, m)
+				}
+			}
+			if emitErr := emit(event); emitErr != nil && err == nil {
+				err = emitErr
+			}
+		}
+		return result, err
+	}
+
+	resp, err := s.SearchStreamEndpoint(ctx, userSearchStreamRequest{userSearchRequest: newUserSearchRequest(q), Emit: emit})
+	if err != nil {
+		return searchResult{Data: []mediaObject{}}, err
+	}
+	response := resp.(userSearchResponse)
+	if response.Err != "" {
+		return response.searchResult(), errors.New(response.Err)
+	}
+	return response.searchResult(), nil
+}
+
+func (s Set) ServiceStatus(ctx context.Context) (healthReport, error) {
+	resp, err := s.ServiceStatusEndpoint(ctx, serviceStatusRequest{})
+	if err != nil {
+		return healthReport{Status: stateUnhealthy, Code: http.StatusServiceUnavailable}, err
+	}
+	response := resp.(serviceStatusResponse)
+	report := healthReport{Status: response.Status, Code: response.Code, Dependencies: response.Dependencies}
+	if response.Err != "" {
+		return report, errors.New(response.Err)
+	}
+	return report, nil
+}
+
 func makeUserSearchEndpoint(service QueryService) endpoint.Endpoint {
 	return func(c context.Context, request interface{}) (interface{}, error) {
 		req := request.(userSearchRequest)
-		searchResult, err := service.Search(c, req.Query)
+		searchResult, err := service.Search(c, req.searchQuery())
+		if isBadRequest(err) {
+			return nil, err
+		}
+		response := newUserSearchResponse(searchResult)
+		if err != nil {
+			response.Err, response.RequestID = err.Error(), requestid.FromContext(c)
+		}
+		return response, nil
+	}
+}
+
+func makeUserSearchStreamEndpoint(service QueryService) endpoint.Endpoint {
+	return func(c context.Context, request interface{}) (interface{}, error) {
+		req := request.(userSearchStreamRequest)
+		searchResult, err := service.StreamSearch(c, req.searchQuery(), req.Emit)
+		if isBadRequest(err) {
+			return nil, err
+		}
+		response := newUserSearchResponse(searchResult)
 		if err != nil {
-			return userSearchResponse{Data: searchResult, Err: err.Error()}, nil
+			response.Err, response.RequestID = err.Error(), requestid.FromContext(c)
 		}
-		return userSearchResponse{Data: searchResult, Err: ""}, nil
+		return response, nil
 	}
 }
 
 func makeServiceStatusEndpoint(service QueryService) endpoint.Endpoint {
 	return func(c context.Context, request interface{}) (interface{}, error) {
-		serviceStatus, err := service.ServiceStatus(c)
+		report, err := service.ServiceStatus(c)
+		response := serviceStatusResponse{Status: report.Status, Code: report.Code, Dependencies: report.Dependencies}
 		if err != nil {
-			return serviceStatusResponse{Status: serviceStatus, Err: err.Error()}, nil
+			response.Err, response.RequestID = err.Error(), requestid.FromContext(c)
+			if response.Code == 0 {
+				response.Code = http.StatusInternalServerError
+			}
 		}
-		return serviceStatusResponse{Status: serviceStatus, Err: ""}, nil
+		return response, nil
 	}
 }
@@ -0,0 +1,318 @@
+package core
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+
+	kitjwt "github.com/go-kit/kit/auth/jwt"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/golang-jwt/jwt/v4"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+const (
+	bearerChallenge        = `Bearer realm="core"`
+	invalidBearerChallenge = `Bearer realm="core", error="invalid_token"`
+	sourceScopePrefix      = "source:"
+)
+
+type principalContextKey struct{}
+
+// JWTConfig describes the "auth.jwt" section of configs/core. Tokens are
+// verified against Keys and the keys of the JWKS file, and must carry the
+// Issuer and Audience when those are set.
+type JWTConfig struct {
+	Issuer   string   `mapstructure:"issuer"`
+	Audience string   `mapstructure:"audience"`
+	Keys     []JWTKey `mapstructure:"keys"`
+	JWKSFile string   `mapstructure:"jwksFile"`
+}
+
+// JWTKey is a key tokens can be signed with: an HS256 secret or the PEM file
+// of an RS256 public key. ID, when set, must match the kid of the token.
+type JWTKey struct {
+	ID            string `mapstructure:"id"`
+	Algorithm     string `mapstructure:"algorithm"`
+	Secret        string `mapstructure:"secret"`
+	PublicKeyFile string `mapstructure:"publicKeyFile"`
+}
+
+// Principal is who a verified token was issued to.
+type Principal struct {
+	Subject string
+	Scopes  []string
+}
+
+func (p Principal) HasScope(scope string) bool {
+	return contains(p.Scopes, scope)
+}
+
+// PrincipalFromContext returns the principal of a request authenticated with
+// a token, for middleware behind NewJWTMiddleware.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalContextKey{}).(Principal)
+	return p, ok
+}
+
+type verificationKey struct {
+	id        string
+	algorithm string
+	key       interface{}
+}
+
+// jwtClaims reads the scopes from either a space-separated "scope" or a
+// "scp" list.
+type jwtClaims struct {
+	jwt.RegisteredClaims
+	Scope string   `json:"scope,omitempty"`
+	Scp   []string `json:"scp,omitempty"`
+}
+
+func (c jwtClaims) principal() Principal {
+	return Principal{Subject: c.Subject, Scopes: append(strings.Fields(c.Scope), c.Scp...)}
+}
+
+var errKeyMismatch = errors.New("key does not match the token")
+
+type jwtVerifier struct {
+	issuer   string
+	audience string
+	keys     []verificationKey
+	parser   *jwt.Parser
+}
+
+func newJWTVerifier(c JWTConfig) (*jwtVerifier, error) {
+	v := &jwtVerifier{
+		issuer:   c.Issuer,
+		audience: c.Audience,
+		parser:   jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg()})),
+	}
+	for i, k := range c.Keys {
+		key, err := loadJWTKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i, err)
+		}
+		v.keys = append(v.keys, key)
+	}
+	if c.JWKSFile != "" {
+		keys, err := loadJWKS(c.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading JWKS file: %w", err)
+		}
+		v.keys = append(v.keys, keys...)
+	}
+	if len(v.keys) == 0 {
+		return nil, errors.New("no keys to verify tokens with")
+	}
+	return v, nil
+}
+
+func loadJWTKey(k JWTKey) (verificationKey, error) {
+	switch k.Algorithm {
+	case jwt.SigningMethodHS256.Alg():
+		if k.Secret == "" {
+			return verificationKey{}, errors.New("secret is required for HS256")
+		}
+		return verificationKey{k.ID, k.Algorithm, []byte(k.Secret)}, nil
+	case jwt.SigningMethodRS256.Alg():
+		if k.PublicKeyFile == "" {
+			return verificationKey{}, errors.New("publicKeyFile is required for RS256")
+		}
+		pem, err := os.ReadFile(k.PublicKeyFile)
+		if err != nil {
+			return verificationKey{}, err
+		}
+		key, err := jwt.ParseRSAPublicKeyFromPEM(pem)
+		if err != nil {
+			return verificationKey{}, fmt.Errorf("%s: %w", k.PublicKeyFile, err)
+		}
+		return verificationKey{k.ID, k.Algorithm, key}, nil
+	default:
+		return verificationKey{}, fmt.Errorf("unsupported algorithm %q, use HS256 or RS256", k.Algorithm)
+	}
+}
+
+// loadJWKS reads the RSA and symmetric signing keys of a JWKS file. Keys of
+// other types or uses are skipped.
+func loadJWKS(path string) ([]verificationKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Alg string `json:"alg"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			K   string `json:"k"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+	var keys []verificationKey
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch {
+		case k.Kty == "RSA" && (k.Alg == "" || k.Alg == jwt.SigningMethodRS256.Alg()):
+			n, err := base64.RawURLEncoding.DecodeString(k.N)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: n: %w", k.Kid, err)
+			}
+			e, err := base64.RawURLEncoding.DecodeString(k.E)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: e: %w", k.Kid, err)
+			}
+			key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+			keys = append(keys, verificationKey{k.Kid, jwt.SigningMethodRS256.Alg(), key})
+		case k.Kty == "oct" && (k.Alg == "" || k.Alg == jwt.SigningMethodHS256.Alg()):
+			secret, err := base64.RawURLEncoding.DecodeString(k.K)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: k: %w", k.Kid, err)
+			}
+			keys = append(keys, verificationKey{k.Kid, jwt.SigningMethodHS256.Alg(), secret})
+		}
+	}
+	return keys, nil
+}
+
+// verify checks the token's signature against every key that fits its
+// algorithm and kid, then its exp, iss and aud claims.
+func (v *jwtVerifier) verify(token string) (Principal, error) {
+	err := errors.New("no key matches the token")
+	for _, k := range v.keys {
+		var claims jwtClaims
+		_, err = v.parser.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+			if t.Method.Alg() != k.algorithm {
+				return nil, errKeyMismatch
+			}
+			if kid, _ := t.Header["kid"].(string); kid != "" && k.id != "" && kid != k.id {
+				return nil, errKeyMismatch
+			}
+			return k.key, nil
+		})
+		var validation *jwt.ValidationError
+		if errors.As(err, &validation) && (errors.Is(err, errKeyMismatch) || validation.Errors&jwt.ValidationErrorSignatureInvalid != 0) {
+			continue
+		}
+		if err != nil {
+			return Principal{}, err
+		}
+		switch {
+		case claims.ExpiresAt == nil:
+			return Principal{}, errors.New("token has no expiry")
+		case v.issuer != "" && !claims.VerifyIssuer(v.issuer, true):
+			return Principal{}, fmt.Errorf("token is not issued by %q", v.issuer)
+		case v.audience != "" && !claims.VerifyAudience(v.audience, true):
+			return Principal{}, fmt.Errorf("token is not meant for %q", v.audience)
+		}
+		return claims.principal(), nil
+	}
+	if errors.Is(err, errKeyMismatch) {
+		return Principal{}, errors.New("no key matches the token")
+	}
+	return Principal{}, err
+}
+
+// NewJWTMiddleware lets through requests carrying a valid bearer token, as
+// put in the context by the transports, and puts its Principal in the
+// context. Requests without a token go through fallback, or are refused when
+// it is nil.
+func NewJWTMiddleware(c JWTConfig, fallback endpoint.Middleware) (endpoint.Middleware, error) {
+	verifier, err := newJWTVerifier(c)
+	if err != nil {
+		return nil, err
+	}
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		var withoutToken endpoint.Endpoint = func(context.Context, interface{}) (interface{}, error) {
+			return nil, unauthorizedError{errors.New("missing bearer token"), bearerChallenge}
+		}
+		if fallback != nil {
+			withoutToken = fallback(next)
+		}
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			token, _ := ctx.Value(kitjwt.JWTContextKey).(string)
+			if token == "" {
+				return withoutToken(ctx, request)
+			}
+			principal, err := verifier.verify(token)
+			if err != nil {
+				return nil, unauthorizedError{fmt.Errorf("invalid bearer token: %w", err), invalidBearerChallenge}
+			}
+			return next(context.WithValue(ctx, principalContextKey{}, principal), request)
+		}
+	}, nil
+}
+
+// NewSourceScopeMiddleware limits the sources a token can search to those it
+// has a "source:<name>" scope for. A search naming no sources is narrowed to
+// them. Requests without a Principal are left alone.
+func NewSourceScopeMiddleware(sources *SourceRegistry) endpoint.Middleware {
+	restrict := func(ctx context.Context, r userSearchRequest) (userSearchRequest, error) {
+		principal, ok := PrincipalFromContext(ctx)
+		if !ok {
+			return r, nil
+		}
+		var allowed []string
+		for _, name := range sources.Names() {
+			if principal.HasScope(sourceScopePrefix + name) {
+				allowed = append(allowed, name)
+			}
+		}
+		if len(r.Sources) == 0 {
+			if len(allowed) == 0 {
+				return r, forbiddenError{errors.New("token has no source scopes")}
+			}
+			r.Sources = allowed
+			return r, nil
+		}
+		if denied := missing(r.Sources, allowed); len(denied) > 0 {
+			return r, forbiddenError{fmt.Errorf("token may not search sources %v", denied)}
+		}
+		return r, nil
+	}
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			var err error
+			switch r := request.(type) {
+			case userSearchRequest:
+				request, err = restrict(ctx, r)
+			case userSearchStreamRequest:
+				r.userSearchRequest, err = restrict(ctx, r.userSearchRequest)
+				request = r
+			}
+			if err != nil {
+				return nil, err
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// forbiddenError is answered with 403 Forbidden, or PermissionDenied over
+// gRPC.
+type forbiddenError struct {
+	err error
+}
+
+func (e forbiddenError) Error() string { return e.err.Error() }
+
+func (forbiddenError) StatusCode() int { return http.StatusForbidden }
+
+func (e forbiddenError) GRPCStatus() *grpcstatus.Status {
+	return grpcstatus.New(codes.PermissionDenied, e.Error())
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func TestJWTVerifierVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	publicKeyFile := filepath.Join(t.TempDir(), "public.pem")
+	if err := os.WriteFile(publicKeyFile, publicPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	secret := []byte("s3cret")
+
+	rsaOnly := JWTConfig{Issuer: "issuer", Audience: "core", Keys: []JWTKey{{ID: "r1", Algorithm: "RS256", PublicKeyFile: publicKeyFile}}}
+	hmacOnly := JWTConfig{Issuer: "issuer", Audience: "core", Keys: []JWTKey{{Algorithm: "HS256", Secret: string(secret)}}}
+	both := JWTConfig{Issuer: "issuer", Audience: "core", Keys: append(append([]JWTKey{}, hmacOnly.Keys...), rsaOnly.Keys...)}
+
+	claims := func(edit func(jwt.MapClaims)) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"sub":   "app",
+			"iss":   "issuer",
+			"aud":   "core",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"scope": "source:book source:album",
+		}
+		if edit != nil {
+			edit(c)
+		}
+		return c
+	}
+	sign := func(method jwt.SigningMethod, kid string, c jwt.MapClaims, key interface{}) string {
+		token := jwt.NewWithClaims(method, c)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name   string
+		config JWTConfig
+		token  string
+		valid  bool
+	}{
+		{"HS256", hmacOnly, sign(jwt.SigningMethodHS256, "", claims(nil), secret), true},
+		{"RS256", rsaOnly, sign(jwt.SigningMethodRS256, "r1", claims(nil), rsaKey), true},
+		{"RS256 without kid", rsaOnly, sign(jwt.SigningMethodRS256, "", claims(nil), rsaKey), true},
+		{"RS256 among several keys", both, sign(jwt.SigningMethodRS256, "r1", claims(nil), rsaKey), true},
+		{"HS256 signed with the RS256 public key", rsaOnly, sign(jwt.SigningMethodHS256, "r1", claims(nil), publicPEM), false},
+		{"HS256 signed with the RS256 public key among several keys", both, sign(jwt.SigningMethodHS256, "", claims(nil), publicPEM), false},
+		{"alg none", both, sign(jwt.SigningMethodNone, "", claims(nil), jwt.UnsafeAllowNoneSignatureType), false},
+		{"kid matching no key", rsaOnly, sign(jwt.SigningMethodRS256, "r2", claims(nil), rsaKey), false},
+		{"wrong secret", hmacOnly, sign(jwt.SigningMethodHS256, "", claims(nil), []byte("guess")), false},
+		{"missing exp", hmacOnly, sign(jwt.SigningMethodHS256, "", claims(func(c jwt.MapClaims) { delete(c, "exp") }), secret), false},
+		{"expired", hmacOnly, sign(jwt.SigningMethodHS256, "", claims(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }), secret), false},
+		{"wrong iss", hmacOnly, sign(jwt.SigningMethodHS256, "", claims(func(c jwt.MapClaims) { c["iss"] = "someone-else" }), secret), false},
+		{"missing iss", hmacOnly, sign(jwt.SigningMethodHS256, "", claims(func(c jwt.MapClaims) { delete(c, "iss") }), secret), false},
+		{"wrong aud", hmacOnly, sign(jwt.SigningMethodHS256, "", claims(func(c jwt.MapClaims) { c["aud"] = "other-service" }), secret), false},
+		{"aud list including core", hmacOnly, sign(jwt.SigningMethodHS256, "", claims(func(c jwt.MapClaims) { c["aud"] = []string{"other-service", "core"} }), secret), true},
+		{"malformed", hmacOnly, "not.a.token", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier, err := newJWTVerifier(tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			principal, err := verifier.verify(tt.token)
+			if !tt.valid {
+				if err == nil {
+					t.Fatalf("token verified as %+v, want an error", principal)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+			want := Principal{Subject: "app", Scopes: []string{"source:book", "source:album"}}
+			if !reflect.DeepEqual(principal, want) {
+				t.Errorf("principal = %+v, want %+v", principal, want)
+			}
+		})
+	}
+}
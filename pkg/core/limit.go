@@ -0,0 +1,210 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/metrics"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+// clientIdleAfter is how long a client's bucket is kept after its last
+// request.
+const clientIdleAfter = 10 * time.Minute
+
+// RateLimitConfig describes the "rateLimit" section of configs/core.
+type RateLimitConfig struct {
+	PerSecond float64
+	Burst     int
+	// TrustForwardedFor keys anonymous clients by the first address of
+	// X-Forwarded-For, for a core behind a proxy.
+	TrustForwardedFor bool
+}
+
+type clientBucket struct {
+	quota
+	lastSeen time.Time
+}
+
+// clientLimiter keeps a token bucket per client, dropping those idle for
+// clientIdleAfter.
+type clientLimiter struct {
+	perSecond float64
+	burst     int
+
+	mtx       sync.Mutex
+	clients   map[string]*clientBucket
+	lastSweep time.Time
+}
+
+func newClientLimiter(c RateLimitConfig) (*clientLimiter, error) {
+	if c.PerSecond <= 0 {
+		return nil, errors.New("perSecond must be positive")
+	}
+	if c.Burst <= 0 {
+		return nil, errors.New("burst must be positive")
+	}
+	return &clientLimiter{perSecond: c.PerSecond, burst: c.Burst, clients: map[string]*clientBucket{}, lastSweep: time.Now()}, nil
+}
+
+func (l *clientLimiter) take(client string, now time.Time) (time.Duration, bool) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if now.Sub(l.lastSweep) > clientIdleAfter {
+		for id, b := range l.clients {
+			if now.Sub(b.lastSeen) > clientIdleAfter {
+				delete(l.clients, id)
+			}
+		}
+		l.lastSweep = now
+	}
+	b, ok := l.clients[client]
+	if !ok {
+		b = &clientBucket{quota: quota{name: client, buckets: []*rate.Limiter{rate.NewLimiter(rate.Limit(l.perSecond), l.burst)}}}
+		l.clients[client] = b
+	}
+	b.lastSeen = now
+	return b.take(now)
+}
+
+// NewClientRateLimitMiddleware gives every client a token bucket of
+// c.PerSecond requests a second, up to c.Burst at once. Clients are told
+// apart by their token's subject, their API key, or else their address, so
+// it belongs behind the authentication middleware. Refused requests are
+// counted by the kind of client.
+func NewClientRateLimitMiddleware(c RateLimitConfig, limited metrics.Counter) (endpoint.Middleware, error) {
+	limiter, err := newClientLimiter(c)
+	if err != nil {
+		return nil, err
+	}
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			kind, id := clientOf(ctx, c.TrustForwardedFor)
+			if wait, ok := limiter.take(kind+":"+id, time.Now()); !ok {
+				limited.With("client", kind).Add(1)
+				return nil, rateLimitedError{retryAfter: wait}
+			}
+			return next(ctx, request)
+		}
+	}, nil
+}
+
+// NewAddressRateLimitMiddleware gives every client address a token bucket of
+// c.PerSecond requests a second, up to c.Burst at once. It goes in front of
+// the authentication middleware, so that requests with missing or invalid
+// credentials are limited too. Refused requests are counted with the client
+// kind "address".
+func NewAddressRateLimitMiddleware(c RateLimitConfig, limited metrics.Counter) (endpoint.Middleware, error) {
+	limiter, err := newClientLimiter(c)
+	if err != nil {
+		return nil, err
+	}
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			if wait, ok := limiter.take(clientAddress(ctx, c.TrustForwardedFor), time.Now()); !ok {
+				limited.With("client", "address").Add(1)
+				return nil, rateLimitedError{retryAfter: wait}
+			}
+			return next(ctx, request)
+		}
+	}, nil
+}
+
+// clientOf tells who sent a request: the subject of its token, the name of
+// its API key, or its address.
+func clientOf(ctx context.Context, trustForwardedFor bool) (kind string, id string) {
+	if p, ok := PrincipalFromContext(ctx); ok {
+		return "token", p.Subject
+	}
+	if name, ok := ctx.Value(apiKeyNameContextKey{}).(string); ok {
+		return "key", name
+	}
+	return "ip", clientAddress(ctx, trustForwardedFor)
+}
+
+// clientAddress returns the address a request came from, or the first
+// X-Forwarded-For address when trustForwardedFor is set.
+func clientAddress(ctx context.Context, trustForwardedFor bool) string {
+	if trustForwardedFor {
+		if forwarded, _ := ctx.Value(httptransport.ContextKeyRequestXForwardedFor).(string); forwarded != "" {
+			return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+	}
+	addr, _ := ctx.Value(httptransport.ContextKeyRequestRemoteAddr).(string)
+	if p, ok := peer.FromContext(ctx); ok && addr == "" {
+		addr = p.Addr.String()
+	}
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// NewConcurrencyLimitMiddleware refuses requests while max of them are
+// already in flight, rather than queueing them. The requests in flight are
+// tracked in inflight, and the refused ones counted in shed.
+func NewConcurrencyLimitMiddleware(max int, inflight metrics.Gauge, shed metrics.Counter) (endpoint.Middleware, error) {
+	if max <= 0 {
+		return nil, errors.New("maxInFlight must be positive")
+	}
+	slots := make(chan struct{}, max)
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			select {
+			case slots <- struct{}{}:
+			default:
+				shed.Add(1)
+				return nil, overloadedError{}
+			}
+			inflight.Add(1)
+			defer func() {
+				inflight.Add(-1)
+				<-slots
+			}()
+			return next(ctx, request)
+		}
+	}, nil
+}
+
+// rateLimitedError is answered with 429 Too Many Requests and when to try
+// again, or ResourceExhausted over gRPC.
+type rateLimitedError struct {
+	retryAfter time.Duration
+}
+
+func (e rateLimitedError) Error() string {
+	return fmt.Sprintf("too many requests, retry in %s", e.retryAfter.Round(time.Millisecond))
+}
+
+func (rateLimitedError) StatusCode() int { return http.StatusTooManyRequests }
+
+func (e rateLimitedError) Headers() http.Header { return retryAfterHeader(e.retryAfter) }
+
+func (e rateLimitedError) GRPCStatus() *grpcstatus.Status {
+	return grpcstatus.New(codes.ResourceExhausted, e.Error())
+}
+
+// overloadedError is answered with 503 Service Unavailable, or Unavailable
+// over gRPC.
+type overloadedError struct{}
+
+func (overloadedError) Error() string { return "too many searches in flight, try again later" }
+
+func (overloadedError) StatusCode() int { return http.StatusServiceUnavailable }
+
+func (overloadedError) Headers() http.Header { return retryAfterHeader(time.Second) }
+
+func (e overloadedError) GRPCStatus() *grpcstatus.Status {
+	return grpcstatus.New(codes.Unavailable, e.Error())
+}
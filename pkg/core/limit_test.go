@@ -0,0 +1,40 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-kit/kit/metrics/discard"
+	httptransport "github.com/go-kit/kit/transport/http"
+)
+
+func TestAddressRateLimitRunsBeforeAuthentication(t *testing.T) {
+	limit, err := NewAddressRateLimitMiddleware(RateLimitConfig{PerSecond: 1, Burst: 2}, discard.NewCounter())
+	if err != nil {
+		t.Fatal(err)
+	}
+	authenticated := 0
+	search := limit(func(context.Context, interface{}) (interface{}, error) {
+		authenticated++
+		return nil, unauthorizedError{err: errors.New("invalid API key")}
+	})
+	from := func(addr string) context.Context {
+		return context.WithValue(context.Background(), httptransport.ContextKeyRequestRemoteAddr, addr)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := search(from("192.0.2.1:5000"), nil); !errors.As(err, &unauthorizedError{}) {
+			t.Fatalf("request %d: err = %v, want it to reach authentication", i, err)
+		}
+	}
+	if _, err := search(from("192.0.2.1:5001"), nil); !errors.As(err, &rateLimitedError{}) {
+		t.Errorf("third request from the address: err = %v, want it rate limited", err)
+	}
+	if _, err := search(from("192.0.2.2:5000"), nil); !errors.As(err, &unauthorizedError{}) {
+		t.Errorf("request from another address: err = %v, want it to reach authentication", err)
+	}
+	if authenticated != 3 {
+		t.Errorf("%d requests reached authentication, want 3", authenticated)
+	}
+}
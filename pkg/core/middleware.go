@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"time"
 
+	"microservices-with-go/pkg/requestid"
+
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/metrics"
 )
@@ -15,10 +17,10 @@ type LoggingMiddleware struct {
 	Next   QueryService
 }
 
-func (mw LoggingMiddleware) Search(c context.Context, s string) (output []mediaObject, err error) {
+func (mw LoggingMiddleware) Search(c context.Context, q searchQuery) (output searchResult, err error) {
 	defer func(begin time.Time) {
 
-		jsonData, err := json.Marshal(&output)
+		jsonData, err := json.Marshal(&output.Data)
 		var printableOutput string
 		if err != nil {
 			printableOutput = fmt.Sprintf("Entity could not be formatted")
@@ -26,27 +28,59 @@ func (mw LoggingMiddleware) Search(c context.Context, s string) (output []mediaO
 		printableOutput = string(jsonData)
 		_ = mw.Logger.Log(
 			"method", "userQueryPropagation",
-			"input", s,
+			"request_id", requestid.FromContext(c),
+			"input", q.Text,
+			"ranking", q.Ranking,
+			"limit", q.Limit,
+			"cursor", q.Cursor,
+			"types", fmt.Sprint(q.Types),
+			"sources", fmt.Sprint(q.Sources),
 			"output", printableOutput,
+			"timed_out", fmt.Sprint(output.TimedOut),
+			"next_cursor", output.NextCursor,
 			"err", err,
 			"duration", time.Since(begin),
 		)
 	}(time.Now())
 
-	output, err = mw.Next.Search(c, s)
+	output, err = mw.Next.Search(c, q)
 	return
 }
 
-func (mw LoggingMiddleware) ServiceStatus(_ context.Context) (output int, err error) {
+func (mw LoggingMiddleware) StreamSearch(c context.Context, q searchQuery, emit func(sourceEvent) error) (output searchResult, err error) {
+	defer func(begin time.Time) {
+		_ = mw.Logger.Log(
+			"method", "userQueryStream",
+			"request_id", requestid.FromContext(c),
+			"input", q.Text,
+			"ranking", q.Ranking,
+			"limit", q.Limit,
+			"cursor", q.Cursor,
+			"types", fmt.Sprint(q.Types),
+			"sources", fmt.Sprint(q.Sources),
+			"results", len(output.Data),
+			"timed_out", fmt.Sprint(output.TimedOut),
+			"err", err,
+			"duration", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.Next.StreamSearch(c, q, emit)
+	return
+}
+
+func (mw LoggingMiddleware) ServiceStatus(c context.Context) (output healthReport, err error) {
 	defer func(begin time.Time) {
 		_ = mw.Logger.Log(
 			"method", "userQueryServiceStatus",
-			"output", output,
+			"request_id", requestid.FromContext(c),
+			"output", output.Status,
 			"err", err,
 			"duration", time.Since(begin),
 		)
 	}(time.Now())
 
+	output, err = mw.Next.ServiceStatus(c)
 	return
 }
 
@@ -56,23 +90,35 @@ type InstrumentingMiddleware struct {
 	Next           QueryService
 }
 
-func (mw InstrumentingMiddleware) Search(c context.Context, s string) (output []mediaObject, err error) {
+func (mw InstrumentingMiddleware) Search(c context.Context, q searchQuery) (output searchResult, err error) {
 	defer func(begin time.Time) {
 		lvs := []string{"method", "search", "error", fmt.Sprint(err != nil)}
 		mw.RequestCount.With(lvs...).Add(1)
 		mw.RequestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	output, err = mw.Next.Search(c, s)
+	output, err = mw.Next.Search(c, q)
+	return
+}
+
+func (mw InstrumentingMiddleware) StreamSearch(c context.Context, q searchQuery, emit func(sourceEvent) error) (output searchResult, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "streamsearch", "error", fmt.Sprint(err != nil)}
+		mw.RequestCount.With(lvs...).Add(1)
+		mw.RequestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	output, err = mw.Next.StreamSearch(c, q, emit)
 	return
 }
 
-func (mw InstrumentingMiddleware) ServiceStatus(_ context.Context) (output int, err error) {
+func (mw InstrumentingMiddleware) ServiceStatus(c context.Context) (output healthReport, err error) {
 	defer func(begin time.Time) {
 		lvs := []string{"method", "searchservicestatus", "error", fmt.Sprint(err != nil)}
 		mw.RequestCount.With(lvs...).Add(1)
 		mw.RequestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
+	output, err = mw.Next.ServiceStatus(c)
 	return
 }
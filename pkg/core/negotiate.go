@@ -0,0 +1,210 @@
+package core
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	httptransport "github.com/go-kit/kit/transport/http"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	jsonContentType     = "application/json"
+	ndjsonContentType   = "application/x-ndjson"
+	csvContentType      = "text/csv"
+	protobufContentType = "application/x-protobuf"
+)
+
+// responseFormat writes a response in one content type.
+type responseFormat struct {
+	contentType string
+	encode      func(w http.ResponseWriter, response interface{}, params map[string]string) error
+}
+
+// formats maps the media types the core can answer with to their format,
+// including the aliases clients commonly send.
+var formats = map[string]responseFormat{
+	jsonContentType:                   {jsonContentType, encodeJSON},
+	ndjsonContentType:                 {ndjsonContentType, encodeNDJSON},
+	"application/ndjson":              {ndjsonContentType, encodeNDJSON},
+	csvContentType:                    {csvContentType, encodeCSV},
+	protobufContentType:               {protobufContentType, encodeProtobuf},
+	"application/protobuf":            {protobufContentType, encodeProtobuf},
+	"application/vnd.google.protobuf": {protobufContentType, encodeProtobuf},
+}
+
+// negotiate picks the format of the most preferred media type in accept the
+// core supports, with its parameters. A missing Accept header, "*/*" and
+// "application/*" get JSON.
+func negotiate(accept string) (responseFormat, map[string]string, error) {
+	if strings.TrimSpace(accept) == "" {
+		return formats[jsonContentType], nil, nil
+	}
+
+	type mediaRange struct {
+		mediaType string
+		params    map[string]string
+		q         float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, mediaRange{mediaType, params, q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	for _, r := range ranges {
+		switch r.mediaType {
+		case "*/*", "application/*":
+			return formats[jsonContentType], r.params, nil
+		}
+		if format, ok := formats[r.mediaType]; ok {
+			return format, r.params, nil
+		}
+	}
+	return responseFormat{}, nil, notAcceptableError{accept}
+}
+
+// checkAcceptable rejects requests for a content type the core cannot
+// answer with, before any work is done for them.
+func checkAcceptable(r *http.Request) error {
+	_, _, err := negotiate(r.Header.Get("Accept"))
+	return err
+}
+
+// notAcceptableError is answered with 406 Not Acceptable.
+type notAcceptableError struct {
+	accept string
+}
+
+func (e notAcceptableError) Error() string {
+	return fmt.Sprintf("cannot answer with %q, supported types are %v", e.accept, []string{jsonContentType, ndjsonContentType, csvContentType, protobufContentType})
+}
+
+func (notAcceptableError) StatusCode() int { return http.StatusNotAcceptable }
+
+// EncodeResponse writes the response in the format the Accept header of the
+// request asks for. It needs httptransport.PopulateRequestContext.
+func EncodeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	accept, _ := ctx.Value(httptransport.ContextKeyRequestAccept).(string)
+	format, params, err := negotiate(accept)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", format.contentType)
+	w.Header().Add("Vary", "Accept")
+	if sc, ok := response.(httptransport.StatusCoder); ok && sc.StatusCode() != 0 {
+		w.WriteHeader(sc.StatusCode())
+	}
+	return format.encode(w, response, params)
+}
+
+// encodeJSON writes compact JSON, or indented JSON when the media type has
+// the parameter pretty=true.
+func encodeJSON(w http.ResponseWriter, response interface{}, params map[string]string) error {
+	encoder := json.NewEncoder(w)
+	if pretty, _ := strconv.ParseBool(params["pretty"]); pretty {
+		encoder.SetIndent("", "  ")
+	}
+	return encoder.Encode(response)
+}
+
+// encodeNDJSON writes one media item of a search per line, and the rest of
+// the response in headers. Other responses are written as a single line.
+func encodeNDJSON(w http.ResponseWriter, response interface{}, _ map[string]string) error {
+	encoder := json.NewEncoder(w)
+	search, ok := response.(userSearchResponse)
+	if !ok {
+		return encoder.Encode(response)
+	}
+	setSearchHeaders(w, search)
+	for _, m := range search.Data {
+		if err := encoder.Encode(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeCSV writes a row per media item of a search, and the rest of the
+// response in headers. An item's alternates are counted, not listed.
+func encodeCSV(w http.ResponseWriter, response interface{}, _ map[string]string) error {
+	writer := csv.NewWriter(w)
+	switch r := response.(type) {
+	case userSearchResponse:
+		setSearchHeaders(w, r)
+		writer.Write([]string{"title", "artist", "type", "source", "score", "alternates"})
+		for _, m := range r.Data {
+			writer.Write([]string{m.Title, m.Artist, m.EntityType, m.Source, strconv.FormatFloat(m.Score, 'f', -1, 64), strconv.Itoa(len(m.Alternates))})
+		}
+	case serviceStatusResponse:
+		writer.Write([]string{"name", "address", "status", "latency_ms", "err"})
+		writer.Write([]string{"core", "", r.Status, "", r.Err})
+		for _, d := range r.Dependencies {
+			writer.Write([]string{d.Name, d.Address, d.Status, strconv.FormatFloat(d.LatencyMs, 'f', -1, 64), d.Err})
+		}
+	default:
+		return fmt.Errorf("cannot encode %T as CSV", response)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// encodeProtobuf writes the response as the message the gRPC API answers
+// with.
+func encodeProtobuf(w http.ResponseWriter, response interface{}, _ map[string]string) error {
+	var message interface{}
+	var err error
+	switch response.(type) {
+	case userSearchResponse:
+		message, err = encodeGRPCSearchResponse(context.Background(), response)
+	case serviceStatusResponse:
+		message, err = encodeGRPCServiceStatusResponse(context.Background(), response)
+	default:
+		return fmt.Errorf("cannot encode %T as protobuf", response)
+	}
+	if err != nil {
+		return err
+	}
+	body, err := proto.Marshal(message.(proto.Message))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+// setSearchHeaders carries the parts of a search response that formats made
+// of media items only have no room for.
+func setSearchHeaders(w http.ResponseWriter, r userSearchResponse) {
+	if r.NextCursor != "" {
+		w.Header().Set("X-Next-Cursor", r.NextCursor)
+	}
+	if len(r.TimedOut) > 0 {
+		w.Header().Set("X-Timed-Out", strings.Join(r.TimedOut, ","))
+	}
+	if r.Cached {
+		w.Header().Set("X-Cache", "hit")
+	}
+	if r.Err != "" {
+		w.Header().Set("X-Search-Error", r.Err)
+	}
+}
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+const exhausted = -1
+
+// pageCursor holds, per source name, the offset the next page starts at, or
+// exhausted once the source has no more results. It is handed to clients as
+// an opaque string. Sources missing from a cursor start at 0.
+type pageCursor map[string]int
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+func decodeCursor(s string) (pageCursor, error) {
+	cursor := pageCursor{}
+	if s == "" {
+		return cursor, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, errInvalidCursor
+	}
+	for _, offset := range cursor {
+		if offset < exhausted {
+			return nil, errInvalidCursor
+		}
+	}
+	return cursor, nil
+}
+
+func (c pageCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// paginate keeps the first limit items of ranked and returns the cursor of
+// the following page. A source continues after the items it contributed to
+// the page, including the alternates collapsed into them, so results left out
+// of this page are asked for again on the next one. A source that failed
+// keeps its offset, to be retried on the next page, but there is only a next
+// page when some source answered with items: a search every source failed has
+// no cursor, rather than one pointing back at the same page. A source that
+// answered with fewer items than it was asked for, all of them on this page,
+// is exhausted, and there is no next page once every source is.
+func paginate(ranked []mediaObject, limit int, sources []registeredSource, results []sourceResult, cursor pageCursor) ([]mediaObject, string) {
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	consumed := map[string]int{}
+	for _, m := range ranked {
+		consumed[m.Source]++
+		for _, a := range m.Alternates {
+			consumed[a.Source]++
+		}
+	}
+
+	next := pageCursor{}
+	more := false
+	for i, rs := range sources {
+		name := rs.config.Name
+		offset := cursor[name]
+		switch {
+		case offset == exhausted:
+		case results[i].err == nil && rs.config.exhaustedBy(len(results[i].media), limit) && consumed[name] == len(results[i].media):
+			offset = exhausted
+		default:
+			offset += consumed[name]
+			if results[i].err == nil || consumed[name] > 0 {
+				more = true
+			}
+		}
+		next[name] = offset
+	}
+	if !more {
+		return ranked, ""
+	}
+	return ranked, next.encode()
+}
+
+// exhaustedBy reports whether answering a request for limit items with n
+// items shows that the source has no more: it answered with fewer than it
+// would have while it had more.
+func (c SourceConfig) exhaustedBy(n int, limit int) bool {
+	switch {
+	case n == 0:
+		return true
+	case c.ResultLimit == 0:
+		return false
+	case c.ResultLimit < limit:
+		return n < c.ResultLimit
+	}
+	return n < limit
+}
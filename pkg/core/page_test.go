@@ -0,0 +1,226 @@
+package core
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	raw := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	tests := []struct {
+		name   string
+		cursor string
+		want   pageCursor
+		err    error
+	}{
+		{"empty", "", pageCursor{}, nil},
+		{"offsets", raw(`{"book":5,"album":0}`), pageCursor{"book": 5, "album": 0}, nil},
+		{"exhausted", raw(`{"book":-1}`), pageCursor{"book": exhausted}, nil},
+		{"below exhausted", raw(`{"book":-5}`), nil, errInvalidCursor},
+		{"not base64", "***", nil, errInvalidCursor},
+		{"not json", raw(`book=5`), nil, errInvalidCursor},
+		{"not an offset", raw(`{"book":"5"}`), nil, errInvalidCursor},
+		{"fractional offset", raw(`{"book":1.5}`), nil, errInvalidCursor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeCursor(tt.cursor)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cursor = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	sources := []registeredSource{
+		{config: SourceConfig{Name: "book"}},
+		{config: SourceConfig{Name: "album"}},
+	}
+	media := func(source string, n int) []mediaObject {
+		items := make([]mediaObject, n)
+		for i := range items {
+			items[i] = mediaObject{Title: source, Source: source}
+		}
+		return items
+	}
+	failed := errors.New("backend call failed")
+	limited := func(book int, album int) []registeredSource {
+		return []registeredSource{
+			{config: SourceConfig{Name: "book", ResultLimit: book}},
+			{config: SourceConfig{Name: "album", ResultLimit: album}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		sources []registeredSource
+		ranked  []mediaObject
+		limit   int
+		results []sourceResult
+		cursor  pageCursor
+		want    int
+		next    pageCursor
+	}{
+		{
+			name:    "both answer",
+			ranked:  append(media("book", 2), media("album", 2)...),
+			limit:   3,
+			results: []sourceResult{{media: media("book", 2)}, {media: media("album", 2)}},
+			cursor:  pageCursor{},
+			want:    3,
+			next:    pageCursor{"book": 2, "album": 1},
+		},
+		{
+			name:    "continues from the cursor",
+			ranked:  append(media("book", 1), media("album", 1)...),
+			limit:   5,
+			results: []sourceResult{{media: media("book", 1)}, {media: media("album", 1)}},
+			cursor:  pageCursor{"book": 5, "album": 3},
+			want:    2,
+			next:    pageCursor{"book": 6, "album": 4},
+		},
+		{
+			name:    "alternates count for their source",
+			ranked:  []mediaObject{{Source: "book", Alternates: media("album", 1)}},
+			limit:   5,
+			results: []sourceResult{{media: media("book", 1)}, {media: media("album", 1)}},
+			cursor:  pageCursor{},
+			want:    1,
+			next:    pageCursor{"book": 1, "album": 1},
+		},
+		{
+			name:    "empty source is exhausted",
+			ranked:  media("book", 2),
+			limit:   5,
+			results: []sourceResult{{media: media("book", 2)}, {}},
+			cursor:  pageCursor{},
+			want:    2,
+			next:    pageCursor{"book": 2, "album": exhausted},
+		},
+		{
+			name:    "exhausted source stays exhausted",
+			ranked:  media("book", 1),
+			limit:   5,
+			results: []sourceResult{{media: media("book", 1)}, {}},
+			cursor:  pageCursor{"book": 4, "album": exhausted},
+			want:    1,
+			next:    pageCursor{"book": 5, "album": exhausted},
+		},
+		{
+			name:    "every source exhausted",
+			ranked:  nil,
+			limit:   5,
+			results: []sourceResult{{}, {}},
+			cursor:  pageCursor{"book": 7},
+			want:    0,
+			next:    nil,
+		},
+		{
+			name:    "short source is exhausted",
+			sources: limited(5, 5),
+			ranked:  append(media("book", 2), media("album", 5)...),
+			limit:   5,
+			results: []sourceResult{{media: media("book", 2)}, {media: media("album", 5)}},
+			cursor:  pageCursor{},
+			want:    5,
+			next:    pageCursor{"book": exhausted, "album": 3},
+		},
+		{
+			name:    "short source left off the page is not exhausted",
+			sources: limited(5, 5),
+			ranked:  append(media("album", 5), media("book", 2)...),
+			limit:   5,
+			results: []sourceResult{{media: media("book", 2)}, {media: media("album", 5)}},
+			cursor:  pageCursor{},
+			want:    5,
+			next:    pageCursor{"book": 0, "album": 5},
+		},
+		{
+			name:    "source capped below the page size is not short",
+			sources: limited(2, 5),
+			ranked:  append(media("book", 2), media("album", 3)...),
+			limit:   5,
+			results: []sourceResult{{media: media("book", 2)}, {media: media("album", 3)}},
+			cursor:  pageCursor{},
+			want:    5,
+			next:    pageCursor{"book": 2, "album": exhausted},
+		},
+		{
+			name:    "every source answered short",
+			sources: limited(5, 5),
+			ranked:  append(media("book", 1), media("album", 2)...),
+			limit:   5,
+			results: []sourceResult{{media: media("book", 1)}, {media: media("album", 2)}},
+			cursor:  pageCursor{"book": 5, "album": 5},
+			want:    3,
+			next:    nil,
+		},
+		{
+			name:    "failed source is retried from its offset",
+			ranked:  media("book", 2),
+			limit:   5,
+			results: []sourceResult{{media: media("book", 2)}, {err: failed}},
+			cursor:  pageCursor{"album": 3},
+			want:    2,
+			next:    pageCursor{"book": 2, "album": 3},
+		},
+		{
+			name:    "every source failed",
+			ranked:  nil,
+			limit:   5,
+			results: []sourceResult{{err: failed}, {err: failed, timedOut: true}},
+			cursor:  pageCursor{},
+			want:    0,
+			next:    nil,
+		},
+		{
+			name:    "every source failed after a first page",
+			ranked:  nil,
+			limit:   5,
+			results: []sourceResult{{err: failed}, {err: failed}},
+			cursor:  pageCursor{"book": 5, "album": 5},
+			want:    0,
+			next:    nil,
+		},
+		{
+			name:    "timed out source whose partial results were consumed",
+			ranked:  media("album", 2),
+			limit:   5,
+			results: []sourceResult{{err: failed}, {media: media("album", 2), err: failed, timedOut: true}},
+			cursor:  pageCursor{},
+			want:    2,
+			next:    pageCursor{"book": 0, "album": 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sources := sources
+			if tt.sources != nil {
+				sources = tt.sources
+			}
+			page, next := paginate(tt.ranked, tt.limit, sources, tt.results, tt.cursor)
+			if len(page) != tt.want {
+				t.Errorf("page has %d items, want %d", len(page), tt.want)
+			}
+			if tt.next == nil {
+				if next != "" {
+					t.Errorf("next cursor = %q, want none", next)
+				}
+				return
+			}
+			got, err := decodeCursor(next)
+			if err != nil {
+				t.Fatalf("decoding next cursor %q: %v", next, err)
+			}
+			if !reflect.DeepEqual(got, tt.next) {
+				t.Errorf("next cursor = %v, want %v", got, tt.next)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	scoreRanking      = "score"
+	interleaveRanking = "interleave"
+	groupedRanking    = "grouped"
+)
+
+var rankings = []string{scoreRanking, interleaveRanking, groupedRanking}
+
+const (
+	titleTermWeight   = 1.0
+	artistTermWeight  = 0.5
+	titlePhraseBonus  = 1.0
+	artistPhraseBonus = 0.5
+)
+
+func validateRanking(ranking string) error {
+	for _, r := range rankings {
+		if ranking == r {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown ranking %q, supported rankings are %v", ranking, rankings)
+}
+
+// scoreAll scores every item against the query, weighted by its source's
+// weight.
+func scoreAll(query string, media []mediaObject, weight float64) {
+	queryTerms := terms(query)
+	phrase := strings.Join(queryTerms, " ")
+	for i := range media {
+		media[i].Score = weight * score(queryTerms, phrase, media[i])
+	}
+}
+
+// rank orders the merged, scored results with the given strategy. groups
+// holds each source's results in source order.
+func rank(groups [][]mediaObject, ranking string) []mediaObject {
+	for _, group := range groups {
+		sortByScore(group)
+	}
+
+	switch ranking {
+	case interleaveRanking:
+		return interleave(groups)
+	case groupedRanking:
+		return groupByType(groups)
+	default:
+		var ranked []mediaObject
+		for _, group := range groups {
+			ranked = append(ranked, group...)
+		}
+		sortByScore(ranked)
+		return ranked
+	}
+}
+
+// score rates an item by the share of query terms found in its title and
+// artist, with a bonus when the whole query appears as a phrase.
+func score(queryTerms []string, phrase string, m mediaObject) float64 {
+	if len(queryTerms) == 0 {
+		return 0
+	}
+	titleTerms := terms(m.Title)
+	artistTerms := terms(m.Artist)
+
+	var s float64
+	for _, q := range queryTerms {
+		if contains(titleTerms, q) {
+			s += titleTermWeight
+		}
+		if contains(artistTerms, q) {
+			s += artistTermWeight
+		}
+	}
+	s /= float64(len(queryTerms))
+
+	if strings.Contains(" "+strings.Join(titleTerms, " ")+" ", " "+phrase+" ") {
+		s += titlePhraseBonus
+	}
+	if strings.Contains(" "+strings.Join(artistTerms, " ")+" ", " "+phrase+" ") {
+		s += artistPhraseBonus
+	}
+	return s
+}
+
+func terms(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+func contains(terms []string, term string) bool {
+	for _, t := range terms {
+		if t == term {
+			return true
+		}
+	}
+	return false
+}
+
+func sortByScore(media []mediaObject) {
+	sort.SliceStable(media, func(i, j int) bool { return media[i].Score > media[j].Score })
+}
+
+// interleave takes the best remaining item of each source in turn.
+func interleave(groups [][]mediaObject) []mediaObject {
+	var ranked []mediaObject
+	for i := 0; ; i++ {
+		added := false
+		for _, group := range groups {
+			if i < len(group) {
+				ranked = append(ranked, group[i])
+				added = true
+			}
+		}
+		if !added {
+			return ranked
+		}
+	}
+}
+
+// groupByType keeps items of the same type together, ordering the types by
+// their best item and the items of a type by score.
+func groupByType(groups [][]mediaObject) []mediaObject {
+	byType := map[string][]mediaObject{}
+	var types []string
+	for _, group := range groups {
+		for _, m := range group {
+			if _, ok := byType[m.EntityType]; !ok {
+				types = append(types, m.EntityType)
+			}
+			byType[m.EntityType] = append(byType[m.EntityType], m)
+		}
+	}
+	for _, t := range types {
+		sortByScore(byType[t])
+	}
+	sort.SliceStable(types, func(i, j int) bool { return byType[types[i]][0].Score > byType[types[j]][0].Score })
+
+	var ranked []mediaObject
+	for _, t := range types {
+		ranked = append(ranked, byType[t]...)
+	}
+	return ranked
+}
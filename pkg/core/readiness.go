@@ -0,0 +1,153 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// livenessServiceName is the grpc.health.v1 name liveness is reported under.
+// The empty name reports readiness.
+const livenessServiceName = "liveness"
+
+const (
+	readinessStarting = "starting"
+	readinessReady    = "ready"
+	readinessDraining = "draining"
+)
+
+// Readiness tells whether the core should be sent traffic. It is starting
+// until SetReady is called once startup is done, and draining for good once
+// Drain is called. It is mirrored to a grpc.health.v1 server.
+type Readiness struct {
+	mtx    sync.Mutex
+	state  string
+	health *health.Server
+}
+
+func NewReadiness() *Readiness {
+	r := &Readiness{state: readinessStarting, health: health.NewServer()}
+	r.health.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	r.health.SetServingStatus(livenessServiceName, healthpb.HealthCheckResponse_SERVING)
+	return r
+}
+
+// SetReady marks startup done, unless the core is already draining.
+func (r *Readiness) SetReady() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.state == readinessStarting {
+		r.state = readinessReady
+		r.health.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	}
+}
+
+// Drain marks the core as going away, so that no new traffic is routed to it.
+func (r *Readiness) Drain() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.state = readinessDraining
+	r.health.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+func (r *Readiness) State() string {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.state
+}
+
+// HealthServer returns the health service to register on the gRPC server.
+func (r *Readiness) HealthServer() healthpb.HealthServer {
+	return r.health
+}
+
+// livezHandler answers 200 for as long as the process serves requests.
+func livezHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeProbe(w, http.StatusOK, "ok")
+	})
+}
+
+// readyzHandler answers 200 once the core is ready and 503 while it starts
+// or drains. Without a Readiness to follow the core never becomes ready.
+func readyzHandler(readiness *Readiness) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if readiness == nil {
+			writeProbe(w, http.StatusServiceUnavailable, readinessStarting)
+			return
+		}
+		state := readiness.State()
+		code := http.StatusServiceUnavailable
+		if state == readinessReady {
+			code = http.StatusOK
+		}
+		writeProbe(w, code, state)
+	})
+}
+
+func writeProbe(w http.ResponseWriter, code int, status string) {
+	w.Header().Set("Content-Type", jsonContentType)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"status": status})
+}
+
+// WaitConnected blocks until every source has a connected instance or, once
+// grace has passed, until at least one source has. It returns early only
+// when ctx is done.
+func (r *SourceRegistry) WaitConnected(ctx context.Context, grace time.Duration) error {
+	deadline := time.Now().Add(grace)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		sources := r.list()
+		connected := 0
+		for _, rs := range sources {
+			if c, ok := rs.source.(connector); !ok || c.connected() {
+				connected++
+			}
+		}
+		if connected == len(sources) || (connected > 0 && time.Now().After(deadline)) {
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// connector is a source that knows whether it can reach a backend instance.
+type connector interface {
+	connected() bool
+}
+
+// connected reports whether any instance has a ready connection, asking idle
+// connections to connect.
+func (s *grpcSource) connected() bool {
+	type clientConn interface {
+		GetState() connectivity.State
+		Connect()
+	}
+	ready := false
+	for _, instance := range s.pool.all() {
+		conn, ok := instance.closer.(clientConn)
+		if !ok {
+			continue
+		}
+		switch conn.GetState() {
+		case connectivity.Ready:
+			ready = true
+		case connectivity.Idle:
+			conn.Connect()
+		}
+	}
+	return ready
+}
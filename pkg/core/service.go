@@ -6,87 +6,399 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
-	albumtransport "microservices-with-go/pkg/albumsearch"
-	booktransport "microservices-with-go/pkg/booksearch"
-
+	"github.com/go-kit/kit/ratelimit"
+	"github.com/go-kit/kit/sd/lb"
 	"github.com/go-kit/log"
-	"google.golang.org/grpc"
+	"github.com/sony/gobreaker"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
 )
 
 type mediaObject struct {
-	Title      string `json:"title"`
-	Artist     string `json:"artist"`
-	EntityType string `json:"type"`
+	Title      string  `json:"title"`
+	Artist     string  `json:"artist"`
+	EntityType string  `json:"type"`
+	Source     string  `json:"source"`
+	Score      float64 `json:"score"`
+
+	Alternates []mediaObject `json:"alternates,omitempty"`
+}
+
+type searchQuery struct {
+	Text    string
+	Ranking string
+	Limit   int
+	Cursor  string
+	Types   []string
+	Sources []string
+}
+
+type searchResult struct {
+	Data       []mediaObject
+	TimedOut   []string
+	NextCursor string
+	Sources    []sourceStatus
+	Cached     bool
+}
+
+// degraded reports whether any source failed to answer.
+func (r searchResult) degraded() bool {
+	for _, s := range r.Sources {
+		if s.Status != sourceOK {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	sourceOK          = "ok"
+	sourcePartial     = "partial"
+	sourceError       = "error"
+	sourceTimeout     = "timeout"
+	sourceCircuitOpen = "circuit-open"
+	sourceUnknown     = "unknown"
+)
+
+// sourceStatus reports how a source answered a search.
+type sourceStatus struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Count     int     `json:"count"`
+	Err       string  `json:"err,omitempty"`
+}
+
+// sourceEvent carries the results of one source during a streamed search.
+type sourceEvent struct {
+	Source sourceStatus  `json:"source"`
+	Data   []mediaObject `json:"data"`
+}
+
+const (
+	stateHealthy   = "healthy"
+	stateDegraded  = "degraded"
+	stateUnhealthy = "unhealthy"
+)
+
+// dependencyStatus reports how a backend instance answered a status check.
+type dependencyStatus struct {
+	Name      string  `json:"name"`
+	Address   string  `json:"address,omitempty"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Err       string  `json:"err,omitempty"`
+}
+
+// healthReport is the state of the core, derived from its dependencies, and
+// the HTTP status code it is served with.
+type healthReport struct {
+	Status       string
+	Code         int
+	Dependencies []dependencyStatus
 }
 
 type QueryService interface {
-	Search(context.Context, string) ([]mediaObject, error)
-	ServiceStatus(context.Context) (int, error)
+	Search(context.Context, searchQuery) (searchResult, error)
+	StreamSearch(context.Context, searchQuery, func(sourceEvent) error) (searchResult, error)
+	ServiceStatus(context.Context) (healthReport, error)
+}
+
+// ServiceConfig holds the search defaults of the core.
+type ServiceConfig struct {
+	// Ranking is the strategy used for requests that do not ask for one.
+	Ranking string
+	// DefaultLimit is the page size used for requests that do not ask for
+	// one, and MaxLimit the largest page size a request may ask for.
+	DefaultLimit int
+	MaxLimit     int
+	// StatusTimeout bounds the status checks of the backends, defaulting to
+	// a second.
+	StatusTimeout time.Duration
 }
 
-type userQueryPropagatorService struct{}
+const defaultStatusTimeout = time.Second
 
-func NewService() QueryService { return &userQueryPropagatorService{} }
+type userQueryPropagatorService struct {
+	sources *SourceRegistry
+	config  ServiceConfig
+}
 
-func (s *userQueryPropagatorService) Search(ctx context.Context, query string) ([]mediaObject, error) {
-	if query == "" {
-		return []mediaObject{}, errors.New("Query is empty")
+// withDefaults fills in the options a query leaves out.
+func (c ServiceConfig) withDefaults(q searchQuery) searchQuery {
+	if q.Ranking == "" {
+		q.Ranking = c.Ranking
 	}
+	if q.Limit == 0 {
+		q.Limit = c.DefaultLimit
+	}
+	return q
+}
 
-	fmt.Fprintf(os.Stdout, "query: %v\n", query)
+func NewService(sources *SourceRegistry, config ServiceConfig) (QueryService, error) {
+	if err := validateRanking(config.Ranking); err != nil {
+		return nil, err
+	}
+	if config.DefaultLimit <= 0 || config.MaxLimit < config.DefaultLimit {
+		return nil, fmt.Errorf("invalid page limits: default %d, max %d", config.DefaultLimit, config.MaxLimit)
+	}
+	if config.StatusTimeout <= 0 {
+		config.StatusTimeout = defaultStatusTimeout
+	}
+	return &userQueryPropagatorService{sources: sources, config: config}, nil
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
+type sourceResult struct {
+	media    []mediaObject
+	err      error
+	timedOut bool
+	status   sourceStatus
+}
 
-	bookServiceConnection, err := grpc.Dial("localhost:8081", grpc.WithInsecure(), grpc.WithTimeout(time.Second))
+// Search queries every source matching the query's types and sources filters
+// in parallel, each under its own deadline, and ranks and deduplicates
+// whatever arrived in time. Sources that missed their deadline are listed in
+// TimedOut.
+func (s *userQueryPropagatorService) Search(ctx context.Context, query searchQuery) (searchResult, error) {
+	return s.search(ctx, query, nil)
+}
+
+// StreamSearch runs a search like Search and also passes each source's
+// scored results to emit as soon as the source answers. Sources that can
+// stream also pass on each batch of results as it arrives, with the status
+// "partial", before the event with all their results. If emit fails, the
+// search still completes and its error is returned.
+func (s *userQueryPropagatorService) StreamSearch(ctx context.Context, query searchQuery, emit func(sourceEvent) error) (searchResult, error) {
+	return s.search(ctx, query, emit)
+}
+
+func (s *userQueryPropagatorService) search(ctx context.Context, query searchQuery, emit func(sourceEvent) error) (searchResult, error) {
+	if query.Text == "" {
+		return searchResult{Data: []mediaObject{}}, badRequestError{errors.New("Query is empty")}
+	}
+	query = s.config.withDefaults(query)
+	if err := validateRanking(query.Ranking); err != nil {
+		return searchResult{Data: []mediaObject{}}, badRequestError{err}
+	}
+	if query.Limit < 0 || query.Limit > s.config.MaxLimit {
+		return searchResult{Data: []mediaObject{}}, badRequestError{fmt.Errorf("limit must be between 1 and %d", s.config.MaxLimit)}
+	}
+	cursor, err := decodeCursor(query.Cursor)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "dial error at bookService: %v\n", err)
+		return searchResult{Data: []mediaObject{}}, badRequestError{err}
 	}
-	defer bookServiceConnection.Close()
 
-	albumServiceConnection, err := grpc.Dial("localhost:8082", grpc.WithInsecure(), grpc.WithTimeout(time.Second))
+	fmt.Fprintf(os.Stdout, "query: %v\n", query.Text)
+
+	sources, err := s.sources.filter(query.Types, query.Sources)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "dial error at albumService: %v\n", err)
+		return searchResult{Data: []mediaObject{}}, badRequestError{err}
+	}
+	var emitMtx sync.Mutex
+	var emitErr error
+	publish := func(event sourceEvent) {
+		if event.Data == nil {
+			event.Data = []mediaObject{}
+		}
+		emitMtx.Lock()
+		defer emitMtx.Unlock()
+		if emitErr == nil {
+			emitErr = emit(event)
+		}
 	}
-	defer albumServiceConnection.Close()
 
-	bookServiceClient := booktransport.NewGRPCClient(bookServiceConnection)
-	bookServiceResult, err := bookServiceClient.Find(ctx, query)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "find endpoint error: %v\n", err)
-		bookServiceResult = []booktransport.Book{}
+	results := make([]sourceResult, len(sources))
+	answered := make(chan int, len(sources))
+	pending := 0
+	for i, rs := range sources {
+		offset := cursor[rs.config.Name]
+		if offset == exhausted {
+			continue
+		}
+		pending++
+		go func(i int, rs registeredSource) {
+			var partial func([]mediaObject)
+			if emit != nil {
+				begin := time.Now()
+				partial = func(batch []mediaObject) {
+					scoreAll(query.Text, batch, rs.config.Weight)
+					publish(sourceEvent{
+						Source: sourceStatus{
+							Name:      rs.config.Name,
+							Status:    sourcePartial,
+							LatencyMs: float64(time.Since(begin).Microseconds()) / 1000,
+							Count:     len(batch),
+						},
+						Data: batch,
+					})
+				}
+			}
+			results[i] = find(ctx, rs, query.Text, offset, query.Limit, partial)
+			scoreAll(query.Text, results[i].media, rs.config.Weight)
+			answered <- i
+		}(i, rs)
+	}
+	for ; pending > 0; pending-- {
+		i := <-answered
+		if emit != nil {
+			publish(sourceEvent{Source: results[i].status, Data: results[i].media})
+		}
 	}
 
-	albumServiceClient := albumtransport.NewGRPCClient(albumServiceConnection)
-	albumServiceResult, err := albumServiceClient.Find(ctx, query)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "find endpoint error: %v\n", err)
-		albumServiceResult = []albumtransport.Album{}
+	var result searchResult
+	groups := make([][]mediaObject, len(results))
+	for i, r := range results {
+		groups[i] = r.media
+		if r.timedOut {
+			result.TimedOut = append(result.TimedOut, sources[i].config.Name)
+		}
+		if r.status.Name != "" {
+			result.Sources = append(result.Sources, r.status)
+		}
 	}
+	ranked := dedup(rank(groups, query.Ranking))
+	result.Data, result.NextCursor = paginate(ranked, query.Limit, sources, results, cursor)
+	if result.Data == nil {
+		result.Data = []mediaObject{}
+	}
+	return result, emitErr
+}
+
+// find asks a source for a page of results. If partial is set and the source
+// can stream, partial also gets each batch of results as it arrives, and the
+// batches a failed stream delivered are kept in the result next to its error.
+func find(ctx context.Context, rs registeredSource, query string, offset int, limit int, partial func([]mediaObject)) sourceResult {
+	ctx, cancel := context.WithTimeout(ctx, rs.config.Timeout)
+	defer cancel()
 
-	var mediaResult []mediaObject
-	for _, b := range bookServiceResult {
-		mediaResult = append(mediaResult, mediaObject{
-			b.Title,
-			b.Author,
-			"book",
+	begin := time.Now()
+	var media []mediaObject
+	var err error
+	if streaming, ok := rs.source.(StreamingSource); ok && partial != nil {
+		media, err = streaming.FindStream(ctx, query, offset, limit, func(batch []mediaObject) error {
+			batch = append([]mediaObject(nil), batch...)
+			for i := range batch {
+				batch[i].Source = rs.config.Name
+			}
+			partial(batch)
+			return nil
 		})
+	} else {
+		media, err = rs.source.Find(ctx, query, offset, limit)
 	}
+	status := sourceStatus{
+		Name:      rs.config.Name,
+		Status:    sourceOK,
+		LatencyMs: float64(time.Since(begin).Microseconds()) / 1000,
+		Count:     len(media),
+	}
+	for i := range media {
+		media[i].Source = rs.config.Name
+	}
+	if err != nil {
+		logger.Log("source", rs.config.Name, "during", "Find", "err", err)
+		status.Status, status.Err = classify(ctx, err)
+		return sourceResult{media: media, err: err, timedOut: status.Status == sourceTimeout, status: status}
+	}
+	return sourceResult{media: media, status: status}
+}
 
-	for _, b := range albumServiceResult {
-		mediaResult = append(mediaResult, mediaObject{
-			b.Title,
-			b.Artist,
-			"album",
-		})
+// reportedError is an error a source's backend reported in its response. Its
+// message is meant to be shown, unlike those of failures to reach a backend,
+// which may carry addresses and other internals.
+type reportedError struct{ error }
+
+// classify returns the status of a failed source and a message fit for
+// clients.
+func classify(ctx context.Context, err error) (string, string) {
+	var retryErr lb.RetryError
+	if errors.As(err, &retryErr) {
+		err = retryErr.Final
+	}
+	var reported reportedError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded), errors.Is(err, context.DeadlineExceeded), grpcstatus.Code(err) == codes.DeadlineExceeded:
+		return sourceTimeout, "deadline exceeded"
+	case errors.Is(err, gobreaker.ErrOpenState), errors.Is(err, gobreaker.ErrTooManyRequests):
+		return sourceCircuitOpen, "circuit breaker is open"
+	case errors.Is(err, ratelimit.ErrLimited):
+		return sourceError, "rate limited"
+	case errors.Is(err, lb.ErrNoEndpoints):
+		return sourceError, "no instances available"
+	case errors.As(err, &reported):
+		return sourceError, reported.Error()
 	}
-	return mediaResult, nil
+	if s, ok := grpcstatus.FromError(err); ok {
+		return sourceError, "backend call failed: " + s.Code().String()
+	}
+	return sourceError, "internal error"
 }
 
-func (s *userQueryPropagatorService) ServiceStatus(_ context.Context) (int, error) {
-	return http.StatusOK, nil
+// ServiceStatus checks every backend instance of every source in parallel,
+// under StatusTimeout. The core is healthy when all of them are up,
+// unhealthy when no source has any instance up, and degraded otherwise. A
+// source without instances counts as down. A source that cannot be checked is
+// listed with the status "unknown" and keeps the core from being healthy, as
+// does having no sources at all.
+func (s *userQueryPropagatorService) ServiceStatus(ctx context.Context) (healthReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.config.StatusTimeout)
+	defer cancel()
+
+	sources := s.sources.list()
+	checks := make([][]dependencyStatus, len(sources))
+	var wg sync.WaitGroup
+	for i, rs := range sources {
+		checked, ok := rs.source.(CheckedSource)
+		if !ok {
+			checks[i] = []dependencyStatus{{Name: rs.config.Name, Status: sourceUnknown}}
+			continue
+		}
+		wg.Add(1)
+		go func(i int, rs registeredSource, checked CheckedSource) {
+			defer wg.Done()
+			statuses := checked.ServiceStatus(ctx)
+			if len(statuses) == 0 {
+				statuses = []dependencyStatus{{Status: sourceError, Err: "no instances available"}}
+			}
+			for j := range statuses {
+				statuses[j].Name = rs.config.Name
+			}
+			checks[i] = statuses
+		}(i, rs, checked)
+	}
+	wg.Wait()
+
+	report := healthReport{Dependencies: []dependencyStatus{}}
+	upSources, allUp, unverified := 0, true, len(checks) == 0
+	for _, statuses := range checks {
+		up := false
+		for _, status := range statuses {
+			switch status.Status {
+			case sourceOK:
+				up = true
+			case sourceUnknown:
+				unverified = true
+			default:
+				allUp = false
+			}
+		}
+		if up {
+			upSources++
+		}
+		report.Dependencies = append(report.Dependencies, statuses...)
+	}
+	switch {
+	case allUp && !unverified:
+		report.Status, report.Code = stateHealthy, http.StatusOK
+	case upSources > 0 || unverified:
+		report.Status, report.Code = stateDegraded, http.StatusOK
+	default:
+		report.Status, report.Code = stateUnhealthy, http.StatusServiceUnavailable
+	}
+	return report, nil
 }
 
 var logger log.Logger
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// stallingSource streams one batch of results and then waits for its
+// deadline.
+type stallingSource struct {
+	MediaSource
+	batch []mediaObject
+}
+
+func (s stallingSource) FindStream(ctx context.Context, query string, offset int, limit int, send func([]mediaObject) error) ([]mediaObject, error) {
+	batch := append([]mediaObject(nil), s.batch...)
+	if err := send(batch); err != nil {
+		return nil, err
+	}
+	<-ctx.Done()
+	return batch, ctx.Err()
+}
+
+func TestStreamSearchKeepsPartialResultsOfTimedOutSource(t *testing.T) {
+	source := stallingSource{batch: []mediaObject{{Title: "The Hobbit", Artist: "Tolkien", EntityType: "book"}}}
+	sources := &SourceRegistry{sources: []registeredSource{{
+		config: SourceConfig{Name: "book", Type: "book", Timeout: 20 * time.Millisecond, Weight: 1},
+		source: source,
+	}}}
+	service, err := NewService(sources, ServiceConfig{Ranking: scoreRanking, DefaultLimit: 5, MaxLimit: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var events []sourceEvent
+	result, err := service.StreamSearch(context.Background(), searchQuery{Text: "hobbit"}, func(event sourceEvent) error {
+		events = append(events, event)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 2 || events[0].Source.Status != sourcePartial {
+		t.Fatalf("events = %+v, want a partial event followed by the final one", events)
+	}
+	final := events[1]
+	if final.Source.Status != sourceTimeout || final.Source.Count != 1 || len(final.Data) != 1 {
+		t.Errorf("final event = %+v, want a timeout carrying the streamed item", final)
+	}
+	if len(result.Data) != 1 || result.Data[0].Source != "book" {
+		t.Errorf("data = %+v, want the streamed item", result.Data)
+	}
+	if !reflect.DeepEqual(result.TimedOut, []string{"book"}) {
+		t.Errorf("timed out = %v, want [book]", result.TimedOut)
+	}
+	if len(result.Sources) != 1 || result.Sources[0].Count != 1 {
+		t.Errorf("sources = %+v, want book with 1 item", result.Sources)
+	}
+	next, err := decodeCursor(result.NextCursor)
+	if err != nil {
+		t.Fatalf("decoding next cursor %q: %v", result.NextCursor, err)
+	}
+	if !reflect.DeepEqual(next, pageCursor{"book": 1}) {
+		t.Errorf("next cursor = %v, want book at 1", next)
+	}
+}
+
+func TestServiceStatusWithoutCheckedSources(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources []registeredSource
+		want    []dependencyStatus
+	}{
+		{
+			name:    "no sources",
+			sources: nil,
+			want:    []dependencyStatus{},
+		},
+		{
+			name:    "unchecked source",
+			sources: []registeredSource{{config: SourceConfig{Name: "book"}, source: stallingSource{}}},
+			want:    []dependencyStatus{{Name: "book", Status: sourceUnknown}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, err := NewService(&SourceRegistry{sources: tt.sources}, ServiceConfig{Ranking: scoreRanking, DefaultLimit: 5, MaxLimit: 50})
+			if err != nil {
+				t.Fatal(err)
+			}
+			report, err := service.ServiceStatus(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Status != stateDegraded {
+				t.Errorf("status = %q, want %q", report.Status, stateDegraded)
+			}
+			if !reflect.DeepEqual(report.Dependencies, tt.want) {
+				t.Errorf("dependencies = %+v, want %+v", report.Dependencies, tt.want)
+			}
+		})
+	}
+}
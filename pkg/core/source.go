@@ -0,0 +1,283 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"microservices-with-go/pkg/registry"
+
+	"github.com/go-kit/kit/sd"
+)
+
+// MediaSource is a backend the core fans search queries out to. Each
+// implementation adapts its backend's results to mediaObject. Sources are
+// created once at startup and shared by all requests; a source holding
+// connections should also implement io.Closer.
+type MediaSource interface {
+	Find(ctx context.Context, query string, offset int, limit int) ([]mediaObject, error)
+}
+
+// StreamingSource is a MediaSource whose backend can pass results on as it
+// finds them. FindStream hands each batch to send as it arrives and returns
+// all of them, as Find would. If the stream fails, the batches already sent
+// are returned with the error.
+type StreamingSource interface {
+	MediaSource
+	FindStream(ctx context.Context, query string, offset int, limit int, send func([]mediaObject) error) ([]mediaObject, error)
+}
+
+// CheckedSource is a MediaSource that can check the health of its backend.
+// ServiceStatus reports one dependencyStatus per instance, leaving Name to
+// the caller.
+type CheckedSource interface {
+	MediaSource
+	ServiceStatus(ctx context.Context) []dependencyStatus
+}
+
+// SourceConfig describes one entry of the "sources" list in configs/core.
+type SourceConfig struct {
+	Name          string        `mapstructure:"name"`
+	Type          string        `mapstructure:"type"`
+	Address       string        `mapstructure:"address"`
+	Addresses     []string      `mapstructure:"addresses"`
+	DiscoveryFile string        `mapstructure:"discoveryFile"`
+	Timeout       time.Duration `mapstructure:"timeout"`
+	Weight        float64       `mapstructure:"weight"`
+	Balancer      string        `mapstructure:"balancer"`
+	Retries       int           `mapstructure:"retries"`
+	EjectAfter    int           `mapstructure:"ejectAfter"`
+	CoolOff       time.Duration `mapstructure:"coolOff"`
+	DialTimeout   time.Duration `mapstructure:"dialTimeout"`
+	// ResultLimit is the most results the backend answers a request with,
+	// whatever limit it is asked for. When it is 0 the limit is not known, and
+	// only an empty answer shows that the source is exhausted.
+	ResultLimit int `mapstructure:"resultLimit"`
+}
+
+const (
+	defaultSourceTimeout = 3 * time.Second
+	defaultDialTimeout   = time.Second
+)
+
+func (c SourceConfig) validate() error {
+	switch {
+	case c.Timeout < 0:
+		return errors.New("timeout must not be negative")
+	case c.Weight < 0:
+		return errors.New("weight must not be negative")
+	case c.Retries < 0:
+		return errors.New("retries must not be negative")
+	case c.EjectAfter < 0:
+		return errors.New("ejectAfter must not be negative")
+	case c.CoolOff < 0:
+		return errors.New("coolOff must not be negative")
+	case c.DialTimeout < 0:
+		return errors.New("dialTimeout must not be negative")
+	case c.ResultLimit < 0:
+		return errors.New("resultLimit must not be negative")
+	}
+	return ValidateSourceType(c.Type)
+}
+
+// SourceFactory builds the adapter for a source type from its config and the
+// instancer announcing the source's backend instances. A source it returns
+// owns the instancer and stops it when closed.
+type SourceFactory func(SourceConfig, sd.Instancer) (MediaSource, error)
+
+var sourceFactories = map[string]SourceFactory{}
+
+// RegisterSourceType makes a source type available to the "sources" config.
+func RegisterSourceType(sourceType string, factory SourceFactory) {
+	sourceFactories[sourceType] = factory
+}
+
+// SourceTypes returns the source types that can be used in config.
+func SourceTypes() []string {
+	var types []string
+	for t := range sourceFactories {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// ValidateSourceType reports an error listing the supported types if
+// sourceType has no adapter.
+func ValidateSourceType(sourceType string) error {
+	if _, ok := sourceFactories[sourceType]; !ok {
+		return fmt.Errorf("unknown type %q, supported types are %v", sourceType, SourceTypes())
+	}
+	return nil
+}
+
+type registeredSource struct {
+	config SourceConfig
+	source MediaSource
+}
+
+// SourceRegistry holds the sources a search is fanned out to, in config order.
+// When built with an instance registry, the instances that register themselves
+// are added to the sources of their media type, and a media type registering
+// for the first time without a configured source gets one with default
+// settings.
+type SourceRegistry struct {
+	instances *registry.Registry
+
+	mtx     sync.RWMutex
+	sources []registeredSource
+}
+
+// NewSourceRegistry creates the configured sources. instances may be nil, in
+// which case sources only use the addresses from their config.
+func NewSourceRegistry(configs []SourceConfig, instances *registry.Registry) (*SourceRegistry, error) {
+	sources := &SourceRegistry{instances: instances}
+	for _, c := range configs {
+		if err := sources.add(c); err != nil {
+			sources.Close()
+			return nil, err
+		}
+	}
+	if instances != nil {
+		instances.OnNewMediaType(sources.discovered)
+	}
+	return sources, nil
+}
+
+func (r *SourceRegistry) add(c SourceConfig) error {
+	if c.Name == "" {
+		c.Name = c.Type
+	}
+	if c.Timeout == 0 {
+		c.Timeout = defaultSourceTimeout
+	}
+	if c.Weight == 0 {
+		c.Weight = 1
+	}
+	if c.EjectAfter == 0 {
+		c.EjectAfter = defaultEjectAfter
+	}
+	if c.CoolOff == 0 {
+		c.CoolOff = defaultCoolOff
+	}
+	if c.DialTimeout == 0 {
+		c.DialTimeout = defaultDialTimeout
+	}
+	if err := c.validate(); err != nil {
+		return fmt.Errorf("source %q: %w", c.Name, err)
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	for _, rs := range r.sources {
+		if rs.config.Name == c.Name {
+			return fmt.Errorf("duplicate source %q", c.Name)
+		}
+	}
+	instancer, err := sourceInstancer(c, r.instances)
+	if err != nil {
+		return fmt.Errorf("source %q: %w", c.Name, err)
+	}
+	source, err := sourceFactories[c.Type](c, instancer)
+	if err != nil {
+		instancer.Stop()
+		return fmt.Errorf("source %q: %w", c.Name, err)
+	}
+	r.sources = append(r.sources, registeredSource{config: c, source: source})
+	return nil
+}
+
+// discovered adds a source for a media type that registered itself, unless a
+// configured source of that type already receives its instances.
+func (r *SourceRegistry) discovered(mediaType string) {
+	r.mtx.RLock()
+	for _, rs := range r.sources {
+		if rs.config.Type == mediaType {
+			r.mtx.RUnlock()
+			return
+		}
+	}
+	r.mtx.RUnlock()
+
+	if err := r.add(SourceConfig{Type: mediaType}); err != nil {
+		logger.Log("during", "discovered", "type", mediaType, "err", err)
+		return
+	}
+	logger.Log("source", mediaType, "added", "registration")
+}
+
+func (r *SourceRegistry) list() []registeredSource {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	return r.sources
+}
+
+// Types returns the media types of the sources, sorted.
+func (r *SourceRegistry) Types() []string {
+	seen := map[string]bool{}
+	var types []string
+	for _, rs := range r.list() {
+		if !seen[rs.config.Type] {
+			seen[rs.config.Type] = true
+			types = append(types, rs.config.Type)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Names returns the names of the sources, sorted.
+func (r *SourceRegistry) Names() []string {
+	var names []string
+	for _, rs := range r.list() {
+		names = append(names, rs.config.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// filter returns the sources matching the given types and names. An empty
+// list matches everything; unknown types or names are an error listing the
+// supported values.
+func (r *SourceRegistry) filter(types []string, names []string) ([]registeredSource, error) {
+	if unknown := missing(types, r.Types()); len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown types %v, supported types are %v", unknown, r.Types())
+	}
+	if unknown := missing(names, r.Names()); len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown sources %v, supported sources are %v", unknown, r.Names())
+	}
+	var sources []registeredSource
+	for _, rs := range r.list() {
+		if (len(types) == 0 || contains(types, rs.config.Type)) && (len(names) == 0 || contains(names, rs.config.Name)) {
+			sources = append(sources, rs)
+		}
+	}
+	return sources, nil
+}
+
+func missing(values []string, supported []string) []string {
+	var unknown []string
+	for _, v := range values {
+		if !contains(supported, v) {
+			unknown = append(unknown, v)
+		}
+	}
+	return unknown
+}
+
+// Close releases the connections held by the sources.
+func (r *SourceRegistry) Close() error {
+	var firstErr error
+	for _, rs := range r.list() {
+		if closer, ok := rs.source.(io.Closer); ok {
+			if err := closer.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
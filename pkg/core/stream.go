@@ -0,0 +1,134 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"microservices-with-go/pkg/requestid"
+
+	kitjwt "github.com/go-kit/kit/auth/jwt"
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+// NewSearchStreamHandler serves a search as Server-Sent Events: a "source"
+// event with each source's results as soon as it answers, then a "summary"
+// event with the merged response. The search is read from the query string,
+// as EventSource clients cannot send a body.
+func NewSearchStreamHandler(e endpoint.Endpoint) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := httptransport.PopulateRequestContext(r.Context(), r)
+		ctx = kitjwt.HTTPToContext()(apiKeyToHTTPContext(ctx, r), r)
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			encodeError(ctx, errors.New("streaming is not supported"), w)
+			return
+		}
+		if r.Method != http.MethodGet {
+			encodeError(ctx, methodNotAllowedError{allowed: []string{http.MethodGet}}, w)
+			return
+		}
+		request, err := decodeSearchQueryString(r)
+		if err != nil {
+			encodeError(ctx, err, w)
+			return
+		}
+
+		started := false
+		send := func(event string, data interface{}) error {
+			if !started {
+				started = true
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.Header().Set("Cache-Control", "no-cache")
+				w.WriteHeader(http.StatusOK)
+			}
+			if err := writeEvent(w, event, data); err != nil {
+				return err
+			}
+			flusher.Flush()
+			return nil
+		}
+
+		response, err := e(ctx, userSearchStreamRequest{
+			userSearchRequest: request,
+			Emit:              func(event sourceEvent) error { return send("source", event) },
+		})
+		if err != nil {
+			if !started {
+				encodeError(ctx, err, w)
+				return
+			}
+			send("error", userSearchResponse{Err: err.Error(), RequestID: requestid.FromContext(ctx)})
+			return
+		}
+		send("summary", response)
+	})
+}
+
+func writeEvent(w http.ResponseWriter, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	return err
+}
+
+// decodeSearchQueryString reads a search from the q, ranking, limit, cursor,
+// types and sources query parameters. types and sources may be repeated or
+// comma-separated.
+func decodeSearchQueryString(r *http.Request) (userSearchRequest, error) {
+	values := r.URL.Query()
+	request := userSearchRequest{
+		Query:   values.Get("q"),
+		Ranking: values.Get("ranking"),
+		Cursor:  values.Get("cursor"),
+		Types:   splitList(values["types"]),
+		Sources: splitList(values["sources"]),
+	}
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return userSearchRequest{}, badRequestError{fmt.Errorf("invalid limit %q", limit)}
+		}
+		request.Limit = n
+	}
+	return request, nil
+}
+
+func splitList(values []string) []string {
+	var list []string
+	for _, v := range values {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+// badRequestError is a malformed or invalid request, answered with 400 Bad
+// Request, or InvalidArgument over gRPC.
+type badRequestError struct{ error }
+
+func (badRequestError) StatusCode() int { return http.StatusBadRequest }
+
+func (e badRequestError) GRPCStatus() *grpcstatus.Status {
+	return grpcstatus.New(codes.InvalidArgument, e.Error())
+}
+
+func (e badRequestError) Unwrap() error { return e.error }
+
+// isBadRequest tells whether err rejects the request itself, rather than
+// reporting how serving it went.
+func isBadRequest(err error) bool {
+	var bad badRequestError
+	return errors.As(err, &bad)
+}
@@ -3,46 +3,382 @@ package core
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
+	corepb "microservices-with-go/api/core"
+	"microservices-with-go/pkg/requestid"
+
+	kitjwt "github.com/go-kit/kit/auth/jwt"
+	"github.com/go-kit/kit/circuitbreaker"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/ratelimit"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
 	httptransport "github.com/go-kit/kit/transport/http"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sony/gobreaker"
+	"golang.org/x/time/rate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	grpcstatus "google.golang.org/grpc/status"
 )
 
-func NewHTTPHandler(endpoints Set) http.Handler {
+func NewHTTPHandler(endpoints Set, readiness *Readiness) http.Handler {
 	httpHandler := http.NewServeMux()
 
 	httpHandler.Handle("/search", httptransport.NewServer(
 		endpoints.SearchEndpoint,
 		DecodeSearchRequest,
 		EncodeResponse,
+		httptransport.ServerBefore(httptransport.PopulateRequestContext, apiKeyToHTTPContext, kitjwt.HTTPToContext()),
+		httptransport.ServerErrorEncoder(encodeError),
 	))
+	httpHandler.Handle("/search/stream", NewSearchStreamHandler(endpoints.SearchStreamEndpoint))
 	httpHandler.Handle("/status", httptransport.NewServer(
 		endpoints.ServiceStatusEndpoint,
 		DecodeServiceStatusRequest,
 		EncodeResponse,
+		httptransport.ServerBefore(httptransport.PopulateRequestContext),
+		httptransport.ServerErrorEncoder(encodeError),
 	))
+	httpHandler.Handle("/livez", livezHandler())
+	httpHandler.Handle("/readyz", readyzHandler(readiness))
 	httpHandler.Handle("/metrics", promhttp.Handler())
-	return httpHandler
+	return requestid.HTTPHandler(httpHandler)
 }
 
+// DecodeSearchRequest reads a search from the query string of a GET request
+// or from the JSON body of a POST request.
 func DecodeSearchRequest(_ context.Context, r *http.Request) (interface{}, error) {
-	var request userSearchRequest
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+	if err := checkAcceptable(r); err != nil {
 		return nil, err
 	}
-	return request, nil
+	switch r.Method {
+	case http.MethodGet:
+		return decodeSearchQueryString(r)
+	case http.MethodPost:
+		var request userSearchRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			return nil, badRequestError{fmt.Errorf("invalid search body: %w", err)}
+		}
+		return request, nil
+	default:
+		return nil, methodNotAllowedError{allowed: []string{http.MethodGet, http.MethodPost}}
+	}
+}
+
+// methodNotAllowedError is answered with 405 Method Not Allowed and the
+// methods that are.
+type methodNotAllowedError struct {
+	allowed []string
+}
+
+func (e methodNotAllowedError) Error() string {
+	return fmt.Sprintf("method not allowed, use %s", strings.Join(e.allowed, " or "))
+}
+
+func (methodNotAllowedError) StatusCode() int { return http.StatusMethodNotAllowed }
+
+func (e methodNotAllowedError) Headers() http.Header {
+	return http.Header{"Allow": []string{strings.Join(e.allowed, ", ")}}
 }
 
-func EncodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
-	logger.Log("Json encoder: ", response)
-	return json.NewEncoder(w).Encode(response)
+// errorResponse is the body of an error response.
+type errorResponse struct {
+	Err       string `json:"err"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// encodeError answers err as JSON carrying the request ID, with the status
+// code and headers the error asks for, or 500.
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if headerer, ok := err.(httptransport.Headerer); ok {
+		for k, values := range headerer.Headers() {
+			for _, v := range values {
+				w.Header().Add(k, v)
+			}
+		}
+	}
+	code := http.StatusInternalServerError
+	if sc, ok := err.(httptransport.StatusCoder); ok {
+		code = sc.StatusCode()
+	}
+	w.Header().Set("Content-Type", jsonContentType)
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(errorResponse{Err: err.Error(), RequestID: requestid.FromContext(ctx)})
 }
 
 func DecodeServiceStatusRequest(_ context.Context, r *http.Request) (interface{}, error) {
-	var request serviceStatusRequest
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+	if err := checkAcceptable(r); err != nil {
 		return nil, err
 	}
-	return request, nil
+	return serviceStatusRequest{}, nil
+}
+
+type grpcServer struct {
+	search        grpctransport.Handler
+	serviceStatus grpctransport.Handler
+	corepb.UnimplementedCoreServer
+}
+
+func NewGRPCServer(endpoints Set) corepb.CoreServer {
+	return &grpcServer{
+		search: grpctransport.NewServer(
+			endpoints.SearchEndpoint,
+			decodeGRPCSearchRequest,
+			encodeGRPCSearchResponse,
+			grpctransport.ServerBefore(apiKeyToGRPCContext, kitjwt.GRPCToContext()),
+		),
+		serviceStatus: grpctransport.NewServer(
+			endpoints.ServiceStatusEndpoint,
+			decodeGRPCServiceStatusRequest,
+			encodeGRPCServiceStatusResponse,
+		),
+	}
+}
+
+func (g *grpcServer) Search(ctx context.Context, r *corepb.SearchRequest) (*corepb.SearchResponse, error) {
+	ctx = requestid.IncomingContext(ctx)
+	_, rep, err := g.search.ServeGRPC(ctx, r)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return rep.(*corepb.SearchResponse), nil
+}
+
+func (g *grpcServer) ServiceStatus(ctx context.Context, r *corepb.CoreServiceStatusRequest) (*corepb.CoreServiceStatusResponse, error) {
+	ctx = requestid.IncomingContext(ctx)
+	_, rep, err := g.serviceStatus.ServeGRPC(ctx, r)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return rep.(*corepb.CoreServiceStatusResponse), nil
+}
+
+// grpcError converts err to the status it is answered with, carrying the
+// request ID as a RequestInfo detail.
+func grpcError(ctx context.Context, err error) error {
+	st := grpcstatus.Convert(err)
+	withID, detailErr := st.WithDetails(&errdetails.RequestInfo{RequestId: requestid.FromContext(ctx)})
+	if detailErr != nil {
+		return st.Err()
+	}
+	return withID.Err()
+}
+
+func decodeGRPCSearchRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*corepb.SearchRequest)
+	return userSearchRequest{
+		Query:   req.Query,
+		Ranking: req.Ranking,
+		Limit:   int(req.Limit),
+		Cursor:  req.Cursor,
+		Types:   req.Types,
+		Sources: req.Sources,
+	}, nil
+}
+
+func decodeGRPCServiceStatusRequest(_ context.Context, _ interface{}) (interface{}, error) {
+	return serviceStatusRequest{}, nil
+}
+
+func encodeGRPCSearchResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(userSearchResponse)
+	return &corepb.SearchResponse{
+		Data:       localMediaToPbMedia(reply.Data),
+		TimedOut:   reply.TimedOut,
+		NextCursor: reply.NextCursor,
+		Sources:    localStatusToPbStatus(reply.Sources),
+		Err:        reply.Err,
+		Cached:     reply.Cached,
+		RequestId:  reply.RequestID,
+	}, nil
+}
+
+func encodeGRPCServiceStatusResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(serviceStatusResponse)
+	return &corepb.CoreServiceStatusResponse{
+		Code:         int64(reply.Code),
+		Err:          reply.Err,
+		Status:       reply.Status,
+		Dependencies: localDependencyToPbDependency(reply.Dependencies),
+	}, nil
+}
+
+func localMediaToPbMedia(locals []mediaObject) []*corepb.MediaObject {
+	var pbMedia []*corepb.MediaObject
+	for _, m := range locals {
+		pbMedia = append(pbMedia, &corepb.MediaObject{
+			Title:      m.Title,
+			Artist:     m.Artist,
+			Type:       m.EntityType,
+			Source:     m.Source,
+			Score:      m.Score,
+			Alternates: localMediaToPbMedia(m.Alternates),
+		})
+	}
+	return pbMedia
+}
+
+func localStatusToPbStatus(locals []sourceStatus) []*corepb.SourceStatus {
+	var pbStatus []*corepb.SourceStatus
+	for _, s := range locals {
+		pbStatus = append(pbStatus, &corepb.SourceStatus{
+			Name:      s.Name,
+			Status:    s.Status,
+			LatencyMs: s.LatencyMs,
+			Count:     int32(s.Count),
+			Err:       s.Err,
+		})
+	}
+	return pbStatus
+}
+
+func localDependencyToPbDependency(locals []dependencyStatus) []*corepb.DependencyStatus {
+	var pbDependencies []*corepb.DependencyStatus
+	for _, d := range locals {
+		pbDependencies = append(pbDependencies, &corepb.DependencyStatus{
+			Name:      d.Name,
+			Address:   d.Address,
+			Status:    d.Status,
+			LatencyMs: d.LatencyMs,
+			Err:       d.Err,
+		})
+	}
+	return pbDependencies
+}
+
+// NewGRPCClient returns a QueryService calling the core over conn. Its
+// StreamSearch emits each source's results once the whole search completes,
+// as the gRPC API has no streaming search.
+func NewGRPCClient(conn *grpc.ClientConn) QueryService {
+	limiter := ratelimit.NewErroringLimiter(rate.NewLimiter(rate.Every(time.Second), 100))
+	var searchEndpoint endpoint.Endpoint
+	{
+		searchEndpoint = grpctransport.NewClient(
+			conn,
+			"core",
+			"Search",
+			encodeGRPCSearchRequest,
+			decodeGRPCSearchResponse,
+			corepb.SearchResponse{},
+			grpctransport.ClientBefore(requestid.ContextToGRPC()),
+		).Endpoint()
+		searchEndpoint = limiter(searchEndpoint)
+		searchEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "Search",
+			Timeout: 10 * time.Second,
+		}))(searchEndpoint)
+	}
+
+	var serviceStatusEndpoint endpoint.Endpoint
+	{
+		serviceStatusEndpoint = grpctransport.NewClient(
+			conn,
+			"core",
+			"ServiceStatus",
+			encodeGRPCServiceStatusRequest,
+			decodeGRPCServiceStatusResponse,
+			corepb.CoreServiceStatusResponse{},
+			grpctransport.ClientBefore(requestid.ContextToGRPC()),
+		).Endpoint()
+		serviceStatusEndpoint = limiter(serviceStatusEndpoint)
+		serviceStatusEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    "ServiceStatus",
+			Timeout: 10 * time.Second,
+		}))(serviceStatusEndpoint)
+	}
+
+	return Set{
+		SearchEndpoint:        searchEndpoint,
+		ServiceStatusEndpoint: serviceStatusEndpoint,
+	}
+}
+
+func encodeGRPCSearchRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(userSearchRequest)
+	return &corepb.SearchRequest{
+		Query:   req.Query,
+		Ranking: req.Ranking,
+		Limit:   int32(req.Limit),
+		Cursor:  req.Cursor,
+		Types:   req.Types,
+		Sources: req.Sources,
+	}, nil
+}
+
+func encodeGRPCServiceStatusRequest(_ context.Context, _ interface{}) (interface{}, error) {
+	return &corepb.CoreServiceStatusRequest{}, nil
+}
+
+func decodeGRPCSearchResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(*corepb.SearchResponse)
+	data := pbMediaToLocalMedia(res.Data)
+	if data == nil {
+		data = []mediaObject{}
+	}
+	return userSearchResponse{
+		Data:       data,
+		TimedOut:   res.TimedOut,
+		NextCursor: res.NextCursor,
+		Sources:    pbStatusToLocalStatus(res.Sources),
+		Err:        res.Err,
+		Cached:     res.Cached,
+		RequestID:  res.RequestId,
+	}, nil
+}
+
+func decodeGRPCServiceStatusResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(*corepb.CoreServiceStatusResponse)
+	return serviceStatusResponse{
+		Status:       res.Status,
+		Code:         int(res.Code),
+		Dependencies: pbDependencyToLocalDependency(res.Dependencies),
+		Err:          res.Err,
+	}, nil
+}
+
+func pbMediaToLocalMedia(pbMedia []*corepb.MediaObject) []mediaObject {
+	var media []mediaObject
+	for _, m := range pbMedia {
+		media = append(media, mediaObject{
+			Title:      m.Title,
+			Artist:     m.Artist,
+			EntityType: m.Type,
+			Source:     m.Source,
+			Score:      m.Score,
+			Alternates: pbMediaToLocalMedia(m.Alternates),
+		})
+	}
+	return media
+}
+
+func pbStatusToLocalStatus(pbStatus []*corepb.SourceStatus) []sourceStatus {
+	var status []sourceStatus
+	for _, s := range pbStatus {
+		status = append(status, sourceStatus{
+			Name:      s.Name,
+			Status:    s.Status,
+			LatencyMs: s.LatencyMs,
+			Count:     int(s.Count),
+			Err:       s.Err,
+		})
+	}
+	return status
+}
+
+func pbDependencyToLocalDependency(pbDependencies []*corepb.DependencyStatus) []dependencyStatus {
+	dependencies := []dependencyStatus{}
+	for _, d := range pbDependencies {
+		dependencies = append(dependencies, dependencyStatus{
+			Name:      d.Name,
+			Address:   d.Address,
+			Status:    d.Status,
+			LatencyMs: d.LatencyMs,
+			Err:       d.Err,
+		})
+	}
+	return dependencies
 }
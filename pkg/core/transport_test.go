@@ -0,0 +1,109 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	corepb "microservices-with-go/api/core"
+	"microservices-with-go/pkg/requestid"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+func TestHTTPErrorsCarryRequestID(t *testing.T) {
+	handler := NewHTTPHandler(Set{}, nil)
+	r := httptest.NewRequest(http.MethodGet, "/search?limit=many", nil)
+	r.Header.Set(requestid.HTTPHeader, "req-1")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("code = %d, want 400", w.Code)
+	}
+	var body errorResponse
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("decoding error body: %v", err)
+	}
+	if body.RequestID != "req-1" || body.Err == "" {
+		t.Errorf("body = %+v, want the error with request ID req-1", body)
+	}
+}
+
+func TestGRPCErrorsCarryRequestID(t *testing.T) {
+	server := NewGRPCServer(Set{
+		SearchEndpoint: func(context.Context, interface{}) (interface{}, error) {
+			return nil, badRequestError{errors.New("Query is empty")}
+		},
+	})
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestid.GRPCMetadata, "req-2"))
+	_, err := server.Search(ctx, &corepb.SearchRequest{})
+
+	st := grpcstatus.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Errorf("code = %v, want InvalidArgument", st.Code())
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RequestInfo); ok {
+			if info.RequestId != "req-2" {
+				t.Errorf("request ID = %q, want req-2", info.RequestId)
+			}
+			return
+		}
+	}
+	t.Errorf("details = %v, want a RequestInfo", st.Details())
+}
+
+// failingService answers every search with an error reported in the
+// response rather than rejecting the request.
+type failingService struct {
+	QueryService
+}
+
+func (failingService) Search(context.Context, searchQuery) (searchResult, error) {
+	return searchResult{Data: []mediaObject{}}, errors.New("every source failed")
+}
+
+func TestGRPCSearchErrorsCarryRequestID(t *testing.T) {
+	server := NewGRPCServer(Set{SearchEndpoint: makeUserSearchEndpoint(failingService{})})
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestid.GRPCMetadata, "req-3"))
+	resp, err := server.Search(ctx, &corepb.SearchRequest{Query: "hobbit"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Err == "" || resp.RequestId != "req-3" {
+		t.Errorf("response = %v, want the error with request ID req-3", resp)
+	}
+}
+
+func TestGRPCSearchResponseCarriesCached(t *testing.T) {
+	encoded, err := encodeGRPCSearchResponse(context.Background(), userSearchResponse{Data: []mediaObject{}, Cached: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !encoded.(*corepb.SearchResponse).Cached {
+		t.Fatalf("encoded response is not marked cached")
+	}
+	decoded, err := decodeGRPCSearchResponse(context.Background(), encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.(userSearchResponse).Cached {
+		t.Errorf("decoded response is not marked cached")
+	}
+}
+
+func TestReadyzWithoutReadiness(t *testing.T) {
+	handler := NewHTTPHandler(Set{}, nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("code = %d, want 503", w.Code)
+	}
+}
@@ -0,0 +1,111 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+type instanceRequest struct {
+	MediaType string
+	Address   string
+}
+
+type registerResponse struct {
+	TTL time.Duration
+	Err string
+}
+
+type heartbeatResponse struct {
+	TTL        time.Duration
+	Registered bool
+	Err        string
+}
+
+type deregisterResponse struct {
+	Err string
+}
+
+type Set struct {
+	RegisterEndpoint   endpoint.Endpoint
+	HeartbeatEndpoint  endpoint.Endpoint
+	DeregisterEndpoint endpoint.Endpoint
+}
+
+func NewEndpointSet(service RegistryService) Set {
+	return Set{
+		RegisterEndpoint:   makeRegisterEndpoint(service),
+		HeartbeatEndpoint:  makeHeartbeatEndpoint(service),
+		DeregisterEndpoint: makeDeregisterEndpoint(service),
+	}
+}
+
+func (s Set) Register(ctx context.Context, mediaType string, address string) (time.Duration, error) {
+	resp, err := s.RegisterEndpoint(ctx, instanceRequest{MediaType: mediaType, Address: address})
+	if err != nil {
+		return 0, err
+	}
+	response := resp.(*registerResponse)
+	if response.Err != "" {
+		return 0, errors.New(response.Err)
+	}
+	return response.TTL, nil
+}
+
+func (s Set) Heartbeat(ctx context.Context, mediaType string, address string) (time.Duration, bool, error) {
+	resp, err := s.HeartbeatEndpoint(ctx, instanceRequest{MediaType: mediaType, Address: address})
+	if err != nil {
+		return 0, false, err
+	}
+	response := resp.(*heartbeatResponse)
+	if response.Err != "" {
+		return 0, false, errors.New(response.Err)
+	}
+	return response.TTL, response.Registered, nil
+}
+
+func (s Set) Deregister(ctx context.Context, mediaType string, address string) error {
+	resp, err := s.DeregisterEndpoint(ctx, instanceRequest{MediaType: mediaType, Address: address})
+	if err != nil {
+		return err
+	}
+	response := resp.(*deregisterResponse)
+	if response.Err != "" {
+		return errors.New(response.Err)
+	}
+	return nil
+}
+
+func makeRegisterEndpoint(service RegistryService) endpoint.Endpoint {
+	return func(c context.Context, request interface{}) (interface{}, error) {
+		req := request.(*instanceRequest)
+		ttl, err := service.Register(c, req.MediaType, req.Address)
+		if err != nil {
+			return registerResponse{Err: err.Error()}, nil
+		}
+		return registerResponse{TTL: ttl}, nil
+	}
+}
+
+func makeHeartbeatEndpoint(service RegistryService) endpoint.Endpoint {
+	return func(c context.Context, request interface{}) (interface{}, error) {
+		req := request.(*instanceRequest)
+		ttl, registered, err := service.Heartbeat(c, req.MediaType, req.Address)
+		if err != nil {
+			return heartbeatResponse{Err: err.Error()}, nil
+		}
+		return heartbeatResponse{TTL: ttl, Registered: registered}, nil
+	}
+}
+
+func makeDeregisterEndpoint(service RegistryService) endpoint.Endpoint {
+	return func(c context.Context, request interface{}) (interface{}, error) {
+		req := request.(*instanceRequest)
+		if err := service.Deregister(c, req.MediaType, req.Address); err != nil {
+			return deregisterResponse{Err: err.Error()}, nil
+		}
+		return deregisterResponse{}, nil
+	}
+}
@@ -0,0 +1,101 @@
+package registry
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+)
+
+// Registrar keeps one media service instance registered with the core. It
+// implements go-kit's sd.Registrar.
+type Registrar struct {
+	client    RegistryService
+	mediaType string
+	address   string
+	interval  time.Duration
+	logger    log.Logger
+
+	quit chan struct{}
+	done chan struct{}
+}
+
+// NewRegistrar returns a registrar announcing address for mediaType and
+// renewing the registration every interval.
+func NewRegistrar(client RegistryService, mediaType string, address string, interval time.Duration, logger log.Logger) *Registrar {
+	return &Registrar{
+		client:    client,
+		mediaType: mediaType,
+		address:   address,
+		interval:  interval,
+		logger:    log.With(logger, "registry", mediaType, "address", address),
+	}
+}
+
+// Register registers the instance and keeps sending heartbeats in the
+// background until Deregister is called. A failed registration is retried on
+// the next heartbeat, so the core may be started after the media service.
+func (r *Registrar) Register() {
+	r.quit = make(chan struct{})
+	r.done = make(chan struct{})
+	go r.loop()
+}
+
+// Deregister stops the heartbeats and removes the instance from the core.
+func (r *Registrar) Deregister() {
+	close(r.quit)
+	<-r.done
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.interval)
+	defer cancel()
+	if err := r.client.Deregister(ctx, r.mediaType, r.address); err != nil {
+		r.logger.Log("during", "Deregister", "err", err)
+	}
+}
+
+func (r *Registrar) loop() {
+	defer close(r.done)
+
+	registered := r.register()
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if registered {
+				registered = r.heartbeat()
+			}
+			if !registered {
+				registered = r.register()
+			}
+		case <-r.quit:
+			return
+		}
+	}
+}
+
+func (r *Registrar) register() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), r.interval)
+	defer cancel()
+	ttl, err := r.client.Register(ctx, r.mediaType, r.address)
+	if err != nil {
+		r.logger.Log("during", "Register", "err", err)
+		return false
+	}
+	if ttl <= r.interval {
+		r.logger.Log("warning", "heartbeat interval is not shorter than the registration ttl", "interval", r.interval, "ttl", ttl)
+	}
+	r.logger.Log("registered", true, "ttl", ttl)
+	return true
+}
+
+func (r *Registrar) heartbeat() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), r.interval)
+	defer cancel()
+	_, registered, err := r.client.Heartbeat(ctx, r.mediaType, r.address)
+	if err != nil {
+		r.logger.Log("during", "Heartbeat", "err", err)
+		return false
+	}
+	return registered
+}
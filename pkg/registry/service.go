@@ -0,0 +1,240 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/sd"
+)
+
+type RegistryService interface {
+	Register(ctx context.Context, mediaType string, address string) (time.Duration, error)
+	Heartbeat(ctx context.Context, mediaType string, address string) (time.Duration, bool, error)
+	Deregister(ctx context.Context, mediaType string, address string) error
+}
+
+// Registry keeps the media service instances that registered themselves,
+// grouped by media type. An instance that has not renewed its registration
+// within the TTL is dropped.
+type Registry struct {
+	ttl       time.Duration
+	accept    func(mediaType string) error
+	onNewType func(mediaType string)
+
+	mtx        sync.Mutex
+	instances  map[string]map[string]time.Time
+	instancers map[string][]*instancer
+	quit       chan struct{}
+}
+
+// NewRegistry returns a registry that drops instances after ttl without a
+// heartbeat. Registrations for media types rejected by accept fail.
+func NewRegistry(ttl time.Duration, accept func(mediaType string) error) *Registry {
+	r := &Registry{
+		ttl:        ttl,
+		accept:     accept,
+		instances:  map[string]map[string]time.Time{},
+		instancers: map[string][]*instancer{},
+		quit:       make(chan struct{}),
+	}
+	go r.expireLoop()
+	return r
+}
+
+// OnNewMediaType sets a function called whenever an instance registers for a
+// media type that has no other registered instance.
+func (r *Registry) OnNewMediaType(f func(mediaType string)) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.onNewType = f
+}
+
+func (r *Registry) Register(_ context.Context, mediaType string, address string) (time.Duration, error) {
+	if mediaType == "" || address == "" {
+		return 0, errMissingInstance
+	}
+	if r.accept != nil {
+		if err := r.accept(mediaType); err != nil {
+			return 0, err
+		}
+	}
+
+	r.mtx.Lock()
+	addresses, ok := r.instances[mediaType]
+	if !ok {
+		addresses = map[string]time.Time{}
+		r.instances[mediaType] = addresses
+	}
+	_, renewed := addresses[address]
+	addresses[address] = time.Now().Add(r.ttl)
+	newType := !renewed && len(addresses) == 1
+	if !renewed {
+		r.notify(mediaType)
+	}
+	onNewType := r.onNewType
+	r.mtx.Unlock()
+
+	if !renewed {
+		logger.Log("registered", mediaType, "address", address, "ttl", r.ttl)
+	}
+	if newType && onNewType != nil {
+		onNewType(mediaType)
+	}
+	return r.ttl, nil
+}
+
+// Heartbeat renews a registration. It reports false when the instance is not
+// registered, e.g. because it expired or the registry restarted, in which case
+// the instance should register again.
+func (r *Registry) Heartbeat(_ context.Context, mediaType string, address string) (time.Duration, bool, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	addresses := r.instances[mediaType]
+	if _, ok := addresses[address]; !ok {
+		return r.ttl, false, nil
+	}
+	addresses[address] = time.Now().Add(r.ttl)
+	return r.ttl, true, nil
+}
+
+func (r *Registry) Deregister(_ context.Context, mediaType string, address string) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	addresses := r.instances[mediaType]
+	if _, ok := addresses[address]; !ok {
+		return nil
+	}
+	delete(addresses, address)
+	r.notify(mediaType)
+	logger.Log("deregistered", mediaType, "address", address)
+	return nil
+}
+
+// Stop stops expiring registrations.
+func (r *Registry) Stop() {
+	close(r.quit)
+}
+
+func (r *Registry) expireLoop() {
+	ticker := time.NewTicker(r.ttl / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			r.expire(now)
+		case <-r.quit:
+			return
+		}
+	}
+}
+
+func (r *Registry) expire(now time.Time) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	for mediaType, addresses := range r.instances {
+		changed := false
+		for address, expiry := range addresses {
+			if now.After(expiry) {
+				delete(addresses, address)
+				changed = true
+				logger.Log("expired", mediaType, "address", address)
+			}
+		}
+		if changed {
+			r.notify(mediaType)
+		}
+	}
+}
+
+// notify sends the current instances of mediaType to its instancers. It must
+// be called with r.mtx held.
+func (r *Registry) notify(mediaType string) {
+	for _, i := range r.instancers[mediaType] {
+		i.broadcast(r.addresses(mediaType, i.static))
+	}
+}
+
+func (r *Registry) addresses(mediaType string, static []string) []string {
+	seen := map[string]bool{}
+	var addresses []string
+	for _, address := range static {
+		if !seen[address] {
+			seen[address] = true
+			addresses = append(addresses, address)
+		}
+	}
+	for address := range r.instances[mediaType] {
+		if !seen[address] {
+			seen[address] = true
+			addresses = append(addresses, address)
+		}
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// Instancer returns an sd.Instancer announcing the registered instances of
+// mediaType together with the static addresses.
+func (r *Registry) Instancer(mediaType string, static ...string) sd.Instancer {
+	i := &instancer{
+		registry:    r,
+		mediaType:   mediaType,
+		static:      static,
+		subscribers: map[chan<- sd.Event]struct{}{},
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.instancers[mediaType] = append(r.instancers[mediaType], i)
+	return i
+}
+
+type instancer struct {
+	registry  *Registry
+	mediaType string
+	static    []string
+
+	mtx         sync.Mutex
+	subscribers map[chan<- sd.Event]struct{}
+}
+
+func (i *instancer) Register(ch chan<- sd.Event) {
+	i.registry.mtx.Lock()
+	addresses := i.registry.addresses(i.mediaType, i.static)
+	i.registry.mtx.Unlock()
+
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+	i.subscribers[ch] = struct{}{}
+	ch <- sd.Event{Instances: addresses}
+}
+
+func (i *instancer) Deregister(ch chan<- sd.Event) {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+	delete(i.subscribers, ch)
+}
+
+func (i *instancer) Stop() {
+	i.registry.mtx.Lock()
+	defer i.registry.mtx.Unlock()
+	instancers := i.registry.instancers[i.mediaType]
+	for n, other := range instancers {
+		if other == i {
+			i.registry.instancers[i.mediaType] = append(instancers[:n], instancers[n+1:]...)
+			break
+		}
+	}
+}
+
+func (i *instancer) broadcast(addresses []string) {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+	for ch := range i.subscribers {
+		ch <- sd.Event{Instances: addresses}
+	}
+}
+
+var errMissingInstance = errors.New("media type and address are required")
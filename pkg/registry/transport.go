@@ -0,0 +1,159 @@
+package registry
+
+import (
+	"context"
+	registry "microservices-with-go/api/registry"
+	"os"
+	"time"
+
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/go-kit/log"
+	"google.golang.org/grpc"
+)
+
+type grpcServer struct {
+	register   grpctransport.Handler
+	heartbeat  grpctransport.Handler
+	deregister grpctransport.Handler
+	registry.UnimplementedRegistryServer
+}
+
+func NewGRPCServer(endpoints Set) registry.RegistryServer {
+	return &grpcServer{
+		register: grpctransport.NewServer(
+			endpoints.RegisterEndpoint,
+			decodeGRPCRegisterRequest,
+			encodeGRPCRegisterResponse,
+		),
+		heartbeat: grpctransport.NewServer(
+			endpoints.HeartbeatEndpoint,
+			decodeGRPCHeartbeatRequest,
+			encodeGRPCHeartbeatResponse,
+		),
+		deregister: grpctransport.NewServer(
+			endpoints.DeregisterEndpoint,
+			decodeGRPCDeregisterRequest,
+			encodeGRPCDeregisterResponse,
+		),
+	}
+}
+
+func (g *grpcServer) Register(ctx context.Context, r *registry.RegisterRequest) (*registry.RegisterResponse, error) {
+	_, rep, err := g.register.ServeGRPC(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return rep.(*registry.RegisterResponse), nil
+}
+
+func (g *grpcServer) Heartbeat(ctx context.Context, r *registry.HeartbeatRequest) (*registry.HeartbeatResponse, error) {
+	_, rep, err := g.heartbeat.ServeGRPC(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return rep.(*registry.HeartbeatResponse), nil
+}
+
+func (g *grpcServer) Deregister(ctx context.Context, r *registry.DeregisterRequest) (*registry.DeregisterResponse, error) {
+	_, rep, err := g.deregister.ServeGRPC(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return rep.(*registry.DeregisterResponse), nil
+}
+
+func decodeGRPCRegisterRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*registry.RegisterRequest)
+	return &instanceRequest{MediaType: req.MediaType, Address: req.Address}, nil
+}
+
+func decodeGRPCHeartbeatRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*registry.HeartbeatRequest)
+	return &instanceRequest{MediaType: req.MediaType, Address: req.Address}, nil
+}
+
+func decodeGRPCDeregisterRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*registry.DeregisterRequest)
+	return &instanceRequest{MediaType: req.MediaType, Address: req.Address}, nil
+}
+
+func encodeGRPCRegisterResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(registerResponse)
+	return &registry.RegisterResponse{TtlSeconds: int64(reply.TTL / time.Second), Err: reply.Err}, nil
+}
+
+func encodeGRPCHeartbeatResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(heartbeatResponse)
+	return &registry.HeartbeatResponse{TtlSeconds: int64(reply.TTL / time.Second), Registered: reply.Registered, Err: reply.Err}, nil
+}
+
+func encodeGRPCDeregisterResponse(_ context.Context, grpcReply interface{}) (interface{}, error) {
+	reply := grpcReply.(deregisterResponse)
+	return &registry.DeregisterResponse{Err: reply.Err}, nil
+}
+
+func NewGRPCClient(conn *grpc.ClientConn) RegistryService {
+	return Set{
+		RegisterEndpoint: grpctransport.NewClient(
+			conn,
+			"registry",
+			"Register",
+			encodeGRPCRegisterRequest,
+			decodeGRPCRegisterResponse,
+			registry.RegisterResponse{},
+		).Endpoint(),
+		HeartbeatEndpoint: grpctransport.NewClient(
+			conn,
+			"registry",
+			"Heartbeat",
+			encodeGRPCHeartbeatRequest,
+			decodeGRPCHeartbeatResponse,
+			registry.HeartbeatResponse{},
+		).Endpoint(),
+		DeregisterEndpoint: grpctransport.NewClient(
+			conn,
+			"registry",
+			"Deregister",
+			encodeGRPCDeregisterRequest,
+			decodeGRPCDeregisterResponse,
+			registry.DeregisterResponse{},
+		).Endpoint(),
+	}
+}
+
+func encodeGRPCRegisterRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(instanceRequest)
+	return &registry.RegisterRequest{MediaType: req.MediaType, Address: req.Address}, nil
+}
+
+func encodeGRPCHeartbeatRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(instanceRequest)
+	return &registry.HeartbeatRequest{MediaType: req.MediaType, Address: req.Address}, nil
+}
+
+func encodeGRPCDeregisterRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(instanceRequest)
+	return &registry.DeregisterRequest{MediaType: req.MediaType, Address: req.Address}, nil
+}
+
+func decodeGRPCRegisterResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(*registry.RegisterResponse)
+	return &registerResponse{TTL: time.Duration(res.TtlSeconds) * time.Second, Err: res.Err}, nil
+}
+
+func decodeGRPCHeartbeatResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(*registry.HeartbeatResponse)
+	return &heartbeatResponse{TTL: time.Duration(res.TtlSeconds) * time.Second, Registered: res.Registered, Err: res.Err}, nil
+}
+
+func decodeGRPCDeregisterResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(*registry.DeregisterResponse)
+	return &deregisterResponse{Err: res.Err}, nil
+}
+
+var logger log.Logger
+
+func init() {
+	logger = log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr))
+	logger = log.With(logger, "ts", log.DefaultTimestampUTC)
+}
@@ -0,0 +1,108 @@
+// Package requestid carries the ID of a request from the core's HTTP and
+// gRPC APIs to the backends it calls, so that their logs can be tied
+// together.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// HTTPHeader is the header a request ID is read from and answered in.
+	HTTPHeader = "X-Request-ID"
+	// GRPCMetadata is the metadata key a request ID travels under.
+	GRPCMetadata = "x-request-id"
+
+	maxLength = 128
+)
+
+type contextKey struct{}
+
+// FromContext returns the request ID in ctx, or "" when there is none.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// New returns a random request ID.
+func New() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// accept returns id if it is fit to be logged, or else a new ID.
+func accept(id string) string {
+	if id == "" || len(id) > maxLength {
+		return New()
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return New()
+		}
+	}
+	return id
+}
+
+// HTTPHandler puts the request's X-Request-ID, or a new ID when it has none,
+// in the request context and answers it in the X-Request-ID header of every
+// response, errors included.
+func HTTPHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := accept(r.Header.Get(HTTPHeader))
+		w.Header().Set(HTTPHeader, id)
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), id)))
+	})
+}
+
+// GRPCToContext puts the request ID of the incoming metadata, or a new ID
+// when it has none, in the context and answers it in the response header
+// metadata, errors included.
+func GRPCToContext() grpctransport.ServerRequestFunc {
+	return func(ctx context.Context, md metadata.MD) context.Context {
+		var id string
+		if v := md.Get(GRPCMetadata); len(v) > 0 {
+			id = v[0]
+		}
+		id = accept(id)
+		grpc.SetHeader(ctx, metadata.Pairs(GRPCMetadata, id))
+		return NewContext(ctx, id)
+	}
+}
+
+// ContextToGRPC passes the request ID in ctx on in the outgoing metadata.
+func ContextToGRPC() grpctransport.ClientRequestFunc {
+	return func(ctx context.Context, md *metadata.MD) context.Context {
+		if id := FromContext(ctx); id != "" {
+			md.Set(GRPCMetadata, id)
+		}
+		return ctx
+	}
+}
+
+// OutgoingContext passes the request ID in ctx on to calls made with the
+// returned context, for clients not built with grpctransport.
+func OutgoingContext(ctx context.Context) context.Context {
+	if id := FromContext(ctx); id != "" {
+		return metadata.AppendToOutgoingContext(ctx, GRPCMetadata, id)
+	}
+	return ctx
+}
+
+// IncomingContext is GRPCToContext for handlers not built with
+// grpctransport, such as streaming ones.
+func IncomingContext(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return GRPCToContext()(ctx, md)
+}